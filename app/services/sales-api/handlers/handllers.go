@@ -5,12 +5,39 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"os"
+	"time"
 
 	v1CheckGrp "github.com/Avyukth/service3-clone/app/services/sales-api/handlers/debug/checkgrp"
+	oauthGrp "github.com/Avyukth/service3-clone/app/services/sales-api/handlers/oauth/oauthgrp"
+	v1APIKeyGrp "github.com/Avyukth/service3-clone/app/services/sales-api/handlers/v1/apikeygrp"
+	v1AuditGrp "github.com/Avyukth/service3-clone/app/services/sales-api/handlers/v1/auditgrp"
+	v1ProductGrp "github.com/Avyukth/service3-clone/app/services/sales-api/handlers/v1/productgrp"
+	v1ResetGrp "github.com/Avyukth/service3-clone/app/services/sales-api/handlers/v1/resetgrp"
+	v1RoleGrp "github.com/Avyukth/service3-clone/app/services/sales-api/handlers/v1/rolegrp"
+	v1SaleGrp "github.com/Avyukth/service3-clone/app/services/sales-api/handlers/v1/salegrp"
+	v1SignupGrp "github.com/Avyukth/service3-clone/app/services/sales-api/handlers/v1/signupgrp"
+	v1TenantGrp "github.com/Avyukth/service3-clone/app/services/sales-api/handlers/v1/tenantgrp"
 	v1TestGrp "github.com/Avyukth/service3-clone/app/services/sales-api/handlers/v1/testgrp"
 	v1UserGrp "github.com/Avyukth/service3-clone/app/services/sales-api/handlers/v1/usergrp"
+	v1WebhookGrp "github.com/Avyukth/service3-clone/app/services/sales-api/handlers/v1/webhookgrp"
+	jwksGrp "github.com/Avyukth/service3-clone/app/services/sales-api/handlers/wellknown/jwksgrp"
+	oidcGrp "github.com/Avyukth/service3-clone/app/services/sales-api/handlers/wellknown/oidcgrp"
+	apikeyCore "github.com/Avyukth/service3-clone/business/core/apikey"
+	auditCore "github.com/Avyukth/service3-clone/business/core/audit"
+	oauthCore "github.com/Avyukth/service3-clone/business/core/oauth"
+	productCore "github.com/Avyukth/service3-clone/business/core/product"
+	resetCore "github.com/Avyukth/service3-clone/business/core/reset"
+	roleCore "github.com/Avyukth/service3-clone/business/core/role"
+	saleCore "github.com/Avyukth/service3-clone/business/core/sale"
+	signupCore "github.com/Avyukth/service3-clone/business/core/signup"
+	tenantCore "github.com/Avyukth/service3-clone/business/core/tenant"
 	userCore "github.com/Avyukth/service3-clone/business/core/user"
+	webhookCore "github.com/Avyukth/service3-clone/business/core/webhook"
 	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/mail"
+	"github.com/Avyukth/service3-clone/business/sys/metrics"
+	"github.com/Avyukth/service3-clone/business/sys/policy"
+	"github.com/Avyukth/service3-clone/business/sys/sigtoken"
 	"github.com/Avyukth/service3-clone/business/web/mid"
 	"github.com/Avyukth/service3-clone/foundation/web"
 	"github.com/jmoiron/sqlx"
@@ -24,6 +51,9 @@ type APIMuxConfig struct {
 	Auth     *auth.Auth
 	DB       *sqlx.DB
 	Tracer   trace.Tracer
+	Mailer   mail.Mailer
+	Signer   *sigtoken.Signer
+	Issuer   string
 }
 
 func DebugStandardLibraryMux() *http.ServeMux {
@@ -49,6 +79,7 @@ func DebugMux(build string, log *zap.SugaredLogger, db *sqlx.DB) http.Handler {
 
 	mux.HandleFunc("/debug/readiness", cgh.Readiness)
 	mux.HandleFunc("/debug/liveness", cgh.Liveness)
+	mux.Handle("/metrics", metrics.Handler(db))
 	return mux
 }
 
@@ -56,16 +87,44 @@ func APIMux(cfg APIMuxConfig) *web.App {
 	app := web.NewApp(
 		cfg.Shutdown,
 		mid.Logger(cfg.Log),
-		mid.Errors(cfg.Log),
 		mid.Metrics(),
+		mid.Errors(cfg.Log),
 		mid.Panics(),
 	)
 
 	//
 	v1(app, cfg)
+	oauth(app, cfg)
+	wellKnown(app, cfg)
 	return app
 }
 
+func wellKnown(app *web.App, cfg APIMuxConfig) {
+	jgh := jwksGrp.Handlers{
+		Auth: cfg.Auth,
+	}
+	app.Handle(http.MethodGet, "", "/.well-known/jwks.json", jgh.JWKS)
+
+	ogh := oidcGrp.Handlers{
+		Issuer: cfg.Issuer,
+	}
+	app.Handle(http.MethodGet, "", "/.well-known/openid-configuration", ogh.Discovery)
+}
+
+func oauth(app *web.App, cfg APIMuxConfig) {
+	oagh := oauthGrp.Handlers{
+		OAuth:  oauthCore.NewCore(cfg.Log, cfg.DB),
+		Auth:   cfg.Auth,
+		Issuer: cfg.Issuer,
+	}
+	app.Handle(http.MethodPost, "", "/oauth/token", oagh.Token, mid.RateLimit(mid.KeyByIP, 30, time.Minute))
+	app.Handle(http.MethodGet, "", "/userinfo", oagh.UserInfo, mid.Authenticate(cfg.Auth))
+	app.Handle(http.MethodPost, "", "/userinfo", oagh.UserInfo, mid.Authenticate(cfg.Auth))
+	app.Handle(http.MethodPost, "", "/oauth/clients", oagh.RegisterClient, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.ClientCreate))
+	app.Handle(http.MethodGet, "", "/oauth/clients", oagh.QueryClients, mid.Authenticate(cfg.Auth))
+	app.Handle(http.MethodDelete, "", "/oauth/clients/:client_id", oagh.DeleteClient, mid.Authenticate(cfg.Auth))
+}
+
 func v1(app *web.App, cfg APIMuxConfig) {
 
 	const version = "v1"
@@ -74,17 +133,108 @@ func v1(app *web.App, cfg APIMuxConfig) {
 	}
 
 	app.Handle(http.MethodGet, version, "/test", tgh.Test)
-	app.Handle(http.MethodGet, version, "/testauth", tgh.Test, mid.Authenticate(cfg.Auth), mid.Authorize(auth.RoleAdmin))
+	app.Handle(http.MethodGet, version, "/testauth", tgh.Test, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.TestAuth))
+
+	usrCore := userCore.NewCore(cfg.Log, cfg.DB)
+	cfg.Auth.SetRevoker(usrCore)
+
+	rlCore := roleCore.NewCore(cfg.Log, cfg.DB)
+	cfg.Auth.SetPermissionResolver(rlCore)
+
+	akCore := apikeyCore.NewCore(cfg.Log, cfg.DB)
+	cfg.Auth.SetAPIKeyResolver(akCore)
 
 	ugh := v1UserGrp.Handlers{
-		User: userCore.NewCore(cfg.Log, cfg.DB),
+		User: usrCore,
 		Auth: cfg.Auth,
 	}
-	app.Handle(http.MethodGet, version, "/users/token", ugh.Token)
-	app.Handle(http.MethodGet, version, "/users/:page/:rows", ugh.Query, mid.Authenticate(cfg.Auth))
-	app.Handle(http.MethodGet, version, "/users/:id", ugh.QueryByID, mid.Authenticate(cfg.Auth), mid.Authorize(auth.RoleAdmin))
+	// Token endpoints accept credentials, so they are limited per client to
+	// slow down brute forcing.
+	app.Handle(http.MethodGet, version, "/users/token", ugh.Token, mid.RateLimit(mid.KeyByIP, 10, time.Minute))
+	app.Handle(http.MethodPost, version, "/users/token/refresh", ugh.Refresh, mid.RateLimit(mid.KeyByIP, 30, time.Minute))
+	app.Handle(http.MethodPost, version, "/users/token/revoke", ugh.Revoke, mid.Authenticate(cfg.Auth))
+	app.Handle(http.MethodGet, version, "/users", ugh.Query, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.UserQuery))
+	app.Handle(http.MethodGet, version, "/users/me", ugh.QueryMe, mid.Authenticate(cfg.Auth))
+	app.Handle(http.MethodPut, version, "/users/me", ugh.UpdateMe, mid.Authenticate(cfg.Auth))
+	app.Handle(http.MethodPut, version, "/users/me/password", ugh.ChangePassword, mid.Authenticate(cfg.Auth), mid.RateLimit(mid.KeyBySubject, 10, time.Hour))
+	app.Handle(http.MethodGet, version, "/users/:id", ugh.QueryByID, mid.Authenticate(cfg.Auth), mid.AuthorizeOwner(policy.UserRead, "id"))
+
+	app.Handle(http.MethodPost, version, "/users", ugh.Create, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.UserCreate))
+	app.Handle(http.MethodPut, version, "/users/:id", ugh.Update, mid.Authenticate(cfg.Auth), mid.AuthorizeOwner(policy.UserUpdate, "id"))
+	app.Handle(http.MethodDelete, version, "/users/:id", ugh.Delete, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.UserDelete))
+	app.Handle(http.MethodGet, version, "/users/deleted", ugh.QueryDeleted, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.UserQueryDeleted))
+	app.Handle(http.MethodPost, version, "/users/:id/restore", ugh.Restore, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.UserRestore))
+	app.Handle(http.MethodPost, version, "/users/purge", ugh.Purge, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.UserPurge))
+
+	akgh := v1APIKeyGrp.Handlers{
+		APIKey: akCore,
+	}
+	app.Handle(http.MethodPost, version, "/users/me/apikeys", akgh.Create, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.APIKeyCreate))
+	app.Handle(http.MethodGet, version, "/users/me/apikeys", akgh.QueryMe, mid.Authenticate(cfg.Auth))
+	app.Handle(http.MethodGet, version, "/users/:id/apikeys", akgh.Query, mid.Authenticate(cfg.Auth), mid.AuthorizeOwner(policy.APIKeyQuery, "id"))
+	app.Handle(http.MethodDelete, version, "/users/:id/apikeys/:key_id", akgh.Revoke, mid.Authenticate(cfg.Auth), mid.AuthorizeOwner(policy.APIKeyRevoke, "id"))
+
+	sugh := v1SignupGrp.Handlers{
+		Signup: signupCore.NewCore(cfg.Log, cfg.DB, cfg.Mailer, cfg.Signer),
+	}
+	app.Handle(http.MethodPost, version, "/signup", sugh.Create, mid.RateLimit(mid.KeyByIP, 5, time.Hour))
+	app.Handle(http.MethodPost, version, "/signup/confirm", sugh.Confirm, mid.RateLimit(mid.KeyByIP, 30, time.Minute))
+
+	rgh := v1ResetGrp.Handlers{
+		Reset: resetCore.NewCore(cfg.Log, cfg.DB, resetCore.NewMailNotifier(cfg.Mailer)),
+	}
+	app.Handle(http.MethodPost, version, "/password/reset", rgh.Request, mid.RateLimit(mid.KeyByIP, 5, time.Hour))
+	app.Handle(http.MethodPost, version, "/password/reset/confirm", rgh.Complete, mid.RateLimit(mid.KeyByIP, 30, time.Minute))
+
+	pgh := v1ProductGrp.Handlers{
+		Product: productCore.NewCore(cfg.Log, cfg.DB),
+	}
+	app.Handle(http.MethodGet, version, "/products/:page/:rows", pgh.Query, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.ProductQuery))
+	app.Handle(http.MethodGet, version, "/products/:id", pgh.QueryByID, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.ProductRead))
+	app.Handle(http.MethodPost, version, "/products", pgh.Create, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.ProductCreate))
+	app.Handle(http.MethodPut, version, "/products/:id", pgh.Update, mid.Authenticate(cfg.Auth))
+	app.Handle(http.MethodDelete, version, "/products/:id", pgh.Delete, mid.Authenticate(cfg.Auth))
+
+	sgh := v1SaleGrp.Handlers{
+		Sale: saleCore.NewCore(cfg.Log, cfg.DB),
+	}
+	app.Handle(http.MethodGet, version, "/sales/:page/:rows", sgh.Query, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.SaleQuery))
+	app.Handle(http.MethodGet, version, "/sales/:id", sgh.QueryByID, mid.Authenticate(cfg.Auth))
+	app.Handle(http.MethodGet, version, "/users/:id/sales", sgh.QueryByUserID, mid.Authenticate(cfg.Auth), mid.AuthorizeOwner(policy.SaleQueryByUser, "id"))
+	app.Handle(http.MethodPost, version, "/sales", sgh.Create, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.SaleCreate))
+
+	agh := v1AuditGrp.Handlers{
+		Audit: auditCore.NewCore(cfg.Log, cfg.DB),
+	}
+	app.Handle(http.MethodGet, version, "/audit", agh.Query, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.AuditRead))
+
+	rlgh := v1RoleGrp.Handlers{
+		Role: rlCore,
+	}
+	app.Handle(http.MethodGet, version, "/roles", rlgh.Query, mid.Authenticate(cfg.Auth), mid.RequirePermission(cfg.Auth, auth.PermRolesRead))
+	app.Handle(http.MethodGet, version, "/roles/:name", rlgh.QueryByName, mid.Authenticate(cfg.Auth), mid.RequirePermission(cfg.Auth, auth.PermRolesRead))
+	app.Handle(http.MethodPost, version, "/roles", rlgh.Create, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.RoleWrite), mid.RequirePermission(cfg.Auth, auth.PermRolesWrite))
+	app.Handle(http.MethodPut, version, "/roles/:name", rlgh.Update, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.RoleWrite), mid.RequirePermission(cfg.Auth, auth.PermRolesWrite))
+	app.Handle(http.MethodDelete, version, "/roles/:name", rlgh.Delete, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.RoleWrite), mid.RequirePermission(cfg.Auth, auth.PermRolesWrite))
+	app.Handle(http.MethodGet, version, "/permissions", rlgh.QueryPermissions, mid.Authenticate(cfg.Auth), mid.RequirePermission(cfg.Auth, auth.PermRolesRead))
+
+	wgh := v1WebhookGrp.Handlers{
+		Webhook: webhookCore.NewCore(cfg.Log, cfg.DB),
+	}
+	app.Handle(http.MethodGet, version, "/webhooks", wgh.Query, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.WebhookRead))
+	app.Handle(http.MethodGet, version, "/webhooks/:id", wgh.QueryByID, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.WebhookRead))
+	app.Handle(http.MethodPost, version, "/webhooks", wgh.Create, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.WebhookWrite))
+	app.Handle(http.MethodPut, version, "/webhooks/:id", wgh.Update, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.WebhookWrite))
+	app.Handle(http.MethodDelete, version, "/webhooks/:id", wgh.Delete, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.WebhookWrite))
+	app.Handle(http.MethodGet, version, "/webhooks/:id/deliveries", wgh.QueryDeliveries, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.WebhookRead))
+	app.Handle(http.MethodPost, version, "/webhooks/:id/deliveries/:delivery_id/redeliver", wgh.Redeliver, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.WebhookWrite))
 
-	app.Handle(http.MethodPost, version, "/users", ugh.Create, mid.Authenticate(cfg.Auth), mid.Authorize(auth.RoleAdmin))
-	app.Handle(http.MethodPut, version, "/users/:id", ugh.Update, mid.Authenticate(cfg.Auth), mid.Authorize(auth.RoleAdmin))
-	app.Handle(http.MethodDelete, version, "/users/:id", ugh.Delete, mid.Authenticate(cfg.Auth), mid.Authorize(auth.RoleAdmin))
+	tntgh := v1TenantGrp.Handlers{
+		Tenant: tenantCore.NewCore(cfg.Log, cfg.DB),
+	}
+	app.Handle(http.MethodGet, version, "/tenants", tntgh.Query, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.TenantRead))
+	app.Handle(http.MethodGet, version, "/tenants/:id", tntgh.QueryByID, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.TenantRead))
+	app.Handle(http.MethodPost, version, "/tenants", tntgh.Create, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.TenantWrite))
+	app.Handle(http.MethodPut, version, "/tenants/:id", tntgh.Update, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.TenantWrite))
+	app.Handle(http.MethodDelete, version, "/tenants/:id", tntgh.Delete, mid.Authenticate(cfg.Auth), mid.AuthorizeAction(policy.TenantWrite))
 }
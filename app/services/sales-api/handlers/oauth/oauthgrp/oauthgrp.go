@@ -0,0 +1,378 @@
+// Package oauthgrp maintains the group of handlers for the OAuth2
+// authorization server: the token endpoint, client registration and the
+// OpenID Connect userinfo endpoint.
+package oauthgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	oauthCore "github.com/Avyukth/service3-clone/business/core/oauth"
+	"github.com/Avyukth/service3-clone/business/data/store/client"
+	"github.com/Avyukth/service3-clone/business/data/store/user"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/Avyukth/service3-clone/foundation/web"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Scopes lists the scopes a client can request. Asking for openid adds an ID
+// token to the response of user backed grants.
+var Scopes = []string{"openid", "profile", "email"}
+
+type Handlers struct {
+	OAuth  oauthCore.Core
+	Auth   *auth.Auth
+	Issuer string
+}
+
+// tokenResponse is a successful response of the token endpoint as described
+// in RFC 6749 section 5.1.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+}
+
+// errorResponse is an error response of the token endpoint as described in
+// RFC 6749 section 5.2.
+type errorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// Token exchanges a grant for an access token. The request is form encoded
+// and the client authenticates with HTTP Basic auth or with client_id and
+// client_secret in the form.
+func (h Handlers) Token(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if err := r.ParseForm(); err != nil {
+		return respondError(ctx, w, "invalid_request", "unable to parse form", http.StatusBadRequest)
+	}
+	form := r.PostForm
+
+	clientID, secret, basic := r.BasicAuth()
+	if basic {
+		// The credentials are form encoded before being put in the header,
+		// RFC 6749 section 2.3.1.
+		clientID, err = url.QueryUnescape(clientID)
+		if err != nil {
+			return respondError(ctx, w, "invalid_request", "malformed client credentials", http.StatusBadRequest)
+		}
+		secret, err = url.QueryUnescape(secret)
+		if err != nil {
+			return respondError(ctx, w, "invalid_request", "malformed client credentials", http.StatusBadRequest)
+		}
+	} else {
+		clientID = form.Get("client_id")
+		secret = form.Get("client_secret")
+	}
+
+	clt, err := h.OAuth.AuthenticateClient(ctx, clientID, secret)
+	if err != nil {
+		if errors.Is(err, oauthCore.ErrInvalidClient) {
+			if basic {
+				w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+			}
+			return respondError(ctx, w, "invalid_client", err.Error(), http.StatusUnauthorized)
+		}
+		return fmt.Errorf("clientID[%s]: %w", clientID, err)
+	}
+
+	scopes, ok := parseScope(form.Get("scope"))
+	if !ok {
+		return respondError(ctx, w, "invalid_scope", "supported scopes are "+strings.Join(Scopes, ", "), http.StatusBadRequest)
+	}
+
+	var claims auth.Claims
+	var refresh string
+
+	grant := form.Get("grant_type")
+	switch grant {
+	case client.GrantPassword:
+		username := form.Get("username")
+		password := form.Get("password")
+		if username == "" || password == "" {
+			return respondError(ctx, w, "invalid_request", "username and password are required", http.StatusBadRequest)
+		}
+		claims, refresh, err = h.OAuth.Password(ctx, clt, username, password, v.Now)
+
+	case client.GrantRefreshToken:
+		refreshToken := form.Get("refresh_token")
+		if refreshToken == "" {
+			return respondError(ctx, w, "invalid_request", "refresh_token is required", http.StatusBadRequest)
+		}
+		claims, refresh, err = h.OAuth.Refresh(ctx, clt, refreshToken, v.Now)
+
+	case client.GrantClientCredentials:
+		claims, err = h.OAuth.ClientCredentials(ctx, clt, v.Now)
+
+	case "":
+		return respondError(ctx, w, "invalid_request", "grant_type is required", http.StatusBadRequest)
+
+	default:
+		return respondError(ctx, w, "unsupported_grant_type", "", http.StatusBadRequest)
+	}
+
+	if err != nil {
+		var locked *user.LockedError
+		switch {
+		case errors.As(err, &locked):
+			retry := int(math.Ceil(locked.Until.Sub(v.Now).Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retry))
+			return respondError(ctx, w, "invalid_grant", locked.Error(), http.StatusBadRequest)
+		case errors.Is(err, oauthCore.ErrInvalidGrant):
+			return respondError(ctx, w, "invalid_grant", err.Error(), http.StatusBadRequest)
+		case errors.Is(err, oauthCore.ErrUnauthorizedClient):
+			return respondError(ctx, w, "unauthorized_client", err.Error(), http.StatusBadRequest)
+		case errors.Is(err, oauthCore.ErrInvalidClient):
+			return respondError(ctx, w, "invalid_client", err.Error(), http.StatusUnauthorized)
+		default:
+			return fmt.Errorf("clientID[%s] grant[%s]: %w", clientID, grant, err)
+		}
+	}
+
+	claims.Issuer = h.Issuer
+
+	access, err := h.Auth.GenerateToken(claims)
+	if err != nil {
+		return fmt.Errorf("unable to generate token: %w", err)
+	}
+
+	resp := tokenResponse{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		RefreshToken: refresh,
+		Scope:        strings.Join(scopes, " "),
+	}
+	if claims.ExpiresAt != nil {
+		resp.ExpiresIn = int(claims.ExpiresAt.Sub(v.Now) / time.Second)
+	}
+
+	if grant != client.GrantClientCredentials && hasScope(scopes, "openid") {
+		resp.IDToken, err = h.idToken(ctx, claims, clt.ID, scopes)
+		if err != nil {
+			return fmt.Errorf("clientID[%s]: %w", clientID, err)
+		}
+	}
+
+	return web.Respond(ctx, w, resp, http.StatusOK)
+}
+
+// idToken signs an ID token for the client about the user of the access token
+// claims. It holds the profile claims of the requested scopes, never the roles
+// or permissions of the access token.
+func (h Handlers) idToken(ctx context.Context, claims auth.Claims, clientID string, scopes []string) (string, error) {
+	idc := auth.IDClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    claims.Issuer,
+			Subject:   claims.Subject,
+			Audience:  jwt.ClaimStrings{clientID},
+			ExpiresAt: claims.ExpiresAt,
+			IssuedAt:  claims.IssuedAt,
+		},
+	}
+
+	if hasScope(scopes, "profile") || hasScope(scopes, "email") {
+		usr, err := h.OAuth.UserInfo(ctx, claims)
+		if err != nil {
+			return "", err
+		}
+		if hasScope(scopes, "profile") {
+			idc.Name = usr.Name
+		}
+		if hasScope(scopes, "email") {
+			verified := usr.DateVerified != nil
+			idc.Email = usr.Email
+			idc.EmailVerified = &verified
+		}
+	}
+
+	tkn, err := h.Auth.GenerateIDToken(idc)
+	if err != nil {
+		return "", fmt.Errorf("unable to generate id token: %w", err)
+	}
+
+	return tkn, nil
+}
+
+// userInfo holds the standard OpenID Connect claims about the user together
+// with the tenant and roles.
+type userInfo struct {
+	Subject       string      `json:"sub"`
+	Name          string      `json:"name"`
+	Email         string      `json:"email"`
+	EmailVerified bool        `json:"email_verified"`
+	TenantID      string      `json:"tenant_id"`
+	Roles         []auth.Role `json:"roles"`
+}
+
+// UserInfo returns the claims about the user the access token was issued for.
+func (h Handlers) UserInfo(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	usr, err := h.OAuth.UserInfo(ctx, claims)
+	if err != nil {
+		switch validate.Cause(err) {
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		case database.ErrForbidden:
+			return validate.NewRequestError(err, http.StatusForbidden)
+		default:
+			return fmt.Errorf("ID[%s]: %w", claims.Subject, err)
+		}
+	}
+
+	info := userInfo{
+		Subject:       usr.ID,
+		Name:          usr.Name,
+		Email:         usr.Email,
+		EmailVerified: usr.DateVerified != nil,
+		TenantID:      usr.TenantID,
+		Roles:         usr.Roles,
+	}
+
+	return web.Respond(ctx, w, info, http.StatusOK)
+}
+
+// RegisterClient registers a client for the calling user. The response holds
+// the client secret, which is not shown again.
+func (h Handlers) RegisterClient(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	var nc client.NewClient
+	if err := web.Decode(r, &nc); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	clt, err := h.OAuth.RegisterClient(ctx, claims, nc, v.Now)
+	if err != nil {
+		switch validate.Cause(err) {
+		case client.ErrScope, database.ErrForbidden:
+			return validate.NewRequestError(err, http.StatusForbidden)
+		default:
+			return fmt.Errorf("registering client, nc[%+v]: %w", nc, err)
+		}
+	}
+
+	return web.Respond(ctx, w, clt, http.StatusCreated)
+}
+
+// QueryClients returns the clients registered by the calling user.
+func (h Handlers) QueryClients(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	clts, err := h.OAuth.QueryClients(ctx, claims, claims.Subject)
+	if err != nil {
+		switch validate.Cause(err) {
+		case database.ErrInvalidID:
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		case database.ErrForbidden:
+			return validate.NewRequestError(err, http.StatusForbidden)
+		default:
+			return fmt.Errorf("ID[%s]: %w", claims.Subject, err)
+		}
+	}
+
+	if clts == nil {
+		clts = []client.Client{}
+	}
+
+	return web.Respond(ctx, w, clts, http.StatusOK)
+}
+
+// DeleteClient removes a client. Admins can remove any client of their tenant.
+func (h Handlers) DeleteClient(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	clientID := web.Param(r, "client_id")
+
+	if err := h.OAuth.DeleteClient(ctx, claims, clientID, v.Now); err != nil {
+		switch validate.Cause(err) {
+		case database.ErrInvalidID:
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		case database.ErrForbidden:
+			return validate.NewRequestError(err, http.StatusForbidden)
+		default:
+			return fmt.Errorf("clientID[%s]: %w", clientID, err)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// respondError writes an error response of the token endpoint. These bypass
+// the error middleware since RFC 6749 fixes their shape.
+func respondError(ctx context.Context, w http.ResponseWriter, code string, description string, status int) error {
+	resp := errorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	}
+	return web.Respond(ctx, w, resp, status)
+}
+
+// parseScope splits the space delimited scope parameter, dropping duplicates.
+// It reports false when a scope is not supported.
+func parseScope(scope string) ([]string, bool) {
+	var scopes []string
+	for _, s := range strings.Fields(scope) {
+		if !hasScope(Scopes, s) {
+			return nil, false
+		}
+		if !hasScope(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes, true
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
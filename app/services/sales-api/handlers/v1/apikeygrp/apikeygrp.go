@@ -0,0 +1,122 @@
+// Package apikeygrp maintains the group of handlers for managing API keys.
+package apikeygrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	apikeyCore "github.com/Avyukth/service3-clone/business/core/apikey"
+	"github.com/Avyukth/service3-clone/business/data/store/apikey"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/Avyukth/service3-clone/foundation/web"
+)
+
+type Handlers struct {
+	APIKey apikeyCore.Core
+}
+
+// Create issues an API key for the calling user. The response holds the key,
+// which is not shown again.
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	var nk apikey.NewAPIKey
+	if err := web.Decode(r, &nk); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	key, err := h.APIKey.Create(ctx, claims, nk, v.Now)
+	if err != nil {
+		switch validate.Cause(err) {
+		case apikey.ErrScope, database.ErrForbidden:
+			return validate.NewRequestError(err, http.StatusForbidden)
+		default:
+			return fmt.Errorf("creating new api key, nk[%+v]: %w", nk, err)
+		}
+	}
+
+	return web.Respond(ctx, w, key, http.StatusCreated)
+}
+
+// QueryMe returns the API keys of the calling user.
+func (h Handlers) QueryMe(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	return h.query(ctx, w, claims, claims.Subject)
+}
+
+// Query returns the API keys of the user.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	return h.query(ctx, w, claims, web.Param(r, "id"))
+}
+
+func (h Handlers) query(ctx context.Context, w http.ResponseWriter, claims auth.Claims, userID string) error {
+	keys, err := h.APIKey.QueryByUserID(ctx, claims, userID)
+	if err != nil {
+		switch validate.Cause(err) {
+		case database.ErrInvalidID:
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		case database.ErrForbidden:
+			return validate.NewRequestError(err, http.StatusForbidden)
+		default:
+			return fmt.Errorf("ID[%s]: %w", userID, err)
+		}
+	}
+
+	if keys == nil {
+		keys = []apikey.APIKey{}
+	}
+
+	return web.Respond(ctx, w, keys, http.StatusOK)
+}
+
+// Revoke stops the API key of the user from being accepted.
+func (h Handlers) Revoke(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	id := web.Param(r, "id")
+	keyID := web.Param(r, "key_id")
+
+	if err := h.APIKey.Revoke(ctx, claims, id, keyID, v.Now); err != nil {
+		switch validate.Cause(err) {
+		case database.ErrInvalidID:
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		case database.ErrForbidden:
+			return validate.NewRequestError(err, http.StatusForbidden)
+		default:
+			return fmt.Errorf("ID[%s] KeyID[%s]: %w", id, keyID, err)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
@@ -0,0 +1,113 @@
+// Package auditgrp maintains the group of handlers for reading the audit log.
+package auditgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	auditCore "github.com/Avyukth/service3-clone/business/core/audit"
+	"github.com/Avyukth/service3-clone/business/data/store/audit"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/Avyukth/service3-clone/foundation/web"
+)
+
+const (
+	defaultRows = 20
+	maxRows     = 100
+)
+
+type Handlers struct {
+	Audit auditCore.Core
+}
+
+// Query returns a page of audit entries, newest first. Filters and paging are
+// taken from the query string:
+//
+//	GET /v1/audit?entity=user&entity_id=<id>&action=update&page=1&rows=20
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	qs := r.URL.Query()
+
+	filter, err := parseFilter(qs)
+	if err != nil {
+		return validate.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	pageNumber := 1
+	if page := qs.Get("page"); page != "" {
+		pageNumber, err = strconv.Atoi(page)
+		if err != nil || pageNumber < 1 {
+			return validate.NewRequestError(fmt.Errorf("invalid page format [%s]", page), http.StatusBadRequest)
+		}
+	}
+
+	rowsPerPage := defaultRows
+	if rows := qs.Get("rows"); rows != "" {
+		rowsPerPage, err = strconv.Atoi(rows)
+		if err != nil || rowsPerPage < 1 || rowsPerPage > maxRows {
+			return validate.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+		}
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	adts, err := h.Audit.Query(ctx, claims, filter, pageNumber, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("unable to query audit log: %w", err)
+	}
+
+	if adts == nil {
+		adts = []audit.Audit{}
+	}
+
+	return web.Respond(ctx, w, adts, http.StatusOK)
+}
+
+func parseFilter(qs url.Values) (audit.QueryFilter, error) {
+	var filter audit.QueryFilter
+
+	if actorID := qs.Get("actor_id"); actorID != "" {
+		if err := validate.CheckID(actorID); err != nil {
+			return audit.QueryFilter{}, fmt.Errorf("invalid actor_id [%s]", actorID)
+		}
+		filter.ActorID = &actorID
+	}
+
+	if entity := qs.Get("entity"); entity != "" {
+		filter.Entity = &entity
+	}
+
+	if entityID := qs.Get("entity_id"); entityID != "" {
+		filter.EntityID = &entityID
+	}
+
+	if action := qs.Get("action"); action != "" {
+		filter.Action = &action
+	}
+
+	if start := qs.Get("start_date"); start != "" {
+		t, err := time.Parse(time.RFC3339, start)
+		if err != nil {
+			return audit.QueryFilter{}, fmt.Errorf("invalid start_date [%s]", start)
+		}
+		filter.StartDate = &t
+	}
+
+	if end := qs.Get("end_date"); end != "" {
+		t, err := time.Parse(time.RFC3339, end)
+		if err != nil {
+			return audit.QueryFilter{}, fmt.Errorf("invalid end_date [%s]", end)
+		}
+		filter.EndDate = &t
+	}
+
+	return filter, nil
+}
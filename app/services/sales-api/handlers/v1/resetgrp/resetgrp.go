@@ -0,0 +1,67 @@
+// Package resetgrp maintains the group of handlers for password resets.
+package resetgrp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Avyukth/service3-clone/business/core/reset"
+	"github.com/Avyukth/service3-clone/business/data/store/token"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/Avyukth/service3-clone/foundation/web"
+)
+
+type Handlers struct {
+	Reset reset.Core
+}
+
+// Request sends a password reset token to the owner of the email. It always
+// reports success so it can not be used to find out which emails exist.
+func (h Handlers) Request(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var req struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+	if err := web.Decode(r, &req); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+	if err := validate.Check(req); err != nil {
+		return err
+	}
+
+	if err := h.Reset.Request(ctx, req.Email, v.Now); err != nil {
+		return fmt.Errorf("requesting reset email[%s]: %w", req.Email, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusAccepted)
+}
+
+// Complete sets a new password using a reset token.
+func (h Handlers) Complete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var cr reset.CompleteReset
+	if err := web.Decode(r, &cr); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	if err := h.Reset.Complete(ctx, cr, v.Now); err != nil {
+		switch validate.Cause(err) {
+		case database.ErrAuthenticationFailure, token.ErrResetExpired, token.ErrResetUsed:
+			return validate.NewRequestError(fmt.Errorf("reset token is invalid: %w", validate.Cause(err)), http.StatusBadRequest)
+		default:
+			return fmt.Errorf("completing reset: %w", err)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
@@ -0,0 +1,141 @@
+// Package rolegrp maintains the group of handlers for managing roles and
+// their permissions.
+package rolegrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	roleCore "github.com/Avyukth/service3-clone/business/core/role"
+	"github.com/Avyukth/service3-clone/business/data/store/role"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/Avyukth/service3-clone/foundation/web"
+)
+
+type Handlers struct {
+	Role roleCore.Core
+}
+
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	rls, err := h.Role.Query(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to query for roles: %w", err)
+	}
+
+	if rls == nil {
+		rls = []role.Role{}
+	}
+
+	return web.Respond(ctx, w, rls, http.StatusOK)
+}
+
+func (h Handlers) QueryByName(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	name := web.Param(r, "name")
+
+	rl, err := h.Role.QueryByName(ctx, name)
+	if err != nil {
+		switch validate.Cause(err) {
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("Name[%s]: %w", name, err)
+		}
+	}
+
+	return web.Respond(ctx, w, rl, http.StatusOK)
+}
+
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	var nr role.NewRole
+	if err := web.Decode(r, &nr); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	rl, err := h.Role.Create(ctx, claims, nr, v.Now)
+	if err != nil {
+		switch validate.Cause(err) {
+		case role.ErrExists:
+			return validate.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("creating new role, nr[%+v]: %w", nr, err)
+		}
+	}
+
+	return web.Respond(ctx, w, rl, http.StatusCreated)
+}
+
+func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	var ur role.UpdateRole
+	if err := web.Decode(r, &ur); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	name := web.Param(r, "name")
+	if err := h.Role.Update(ctx, claims, name, ur, v.Now); err != nil {
+		switch validate.Cause(err) {
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		case role.ErrBuiltIn:
+			return validate.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("Name[%s] Role[%+v]: %w", name, &ur, err)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	name := web.Param(r, "name")
+	if err := h.Role.Delete(ctx, claims, name, v.Now); err != nil {
+		switch validate.Cause(err) {
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		case role.ErrBuiltIn:
+			return validate.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("Name[%s]: %w", name, err)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// QueryPermissions returns every permission a role can grant.
+func (h Handlers) QueryPermissions(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return web.Respond(ctx, w, auth.Permissions, http.StatusOK)
+}
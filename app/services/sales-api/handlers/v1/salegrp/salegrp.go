@@ -0,0 +1,134 @@
+package salegrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	saleCore "github.com/Avyukth/service3-clone/business/core/sale"
+	"github.com/Avyukth/service3-clone/business/data/store/sale"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+
+	"github.com/Avyukth/service3-clone/foundation/web"
+)
+
+type Handlers struct {
+	Sale saleCore.Core
+}
+
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	var ns sale.NewSale
+	if err := web.Decode(r, &ns); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	sl, err := h.Sale.Create(ctx, claims, ns, v.Now)
+	if err != nil {
+		var oversell *sale.OversellError
+		if errors.As(err, &oversell) {
+			return validate.NewRequestError(oversell, http.StatusConflict)
+		}
+
+		switch validate.Cause(err) {
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("unable to create sale[%+v]: %w", &ns, err)
+		}
+	}
+
+	return web.Respond(ctx, w, sl, http.StatusCreated)
+}
+
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page := web.Param(r, "page")
+	pageNumber, err := strconv.Atoi(page)
+	if err != nil || pageNumber < 1 {
+		return validate.NewRequestError(fmt.Errorf("invalid page format: [%s]", page), http.StatusBadRequest)
+	}
+
+	rows := web.Param(r, "rows")
+	rowsPerPage, err := strconv.Atoi(rows)
+	if err != nil || rowsPerPage < 1 {
+		return validate.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	sales, err := h.Sale.Query(ctx, claims, pageNumber, rowsPerPage)
+	if err != nil {
+		switch validate.Cause(err) {
+		case database.ErrForbidden:
+			return validate.NewRequestError(err, http.StatusForbidden)
+		default:
+			return fmt.Errorf("unable to query for sales : %w", err)
+		}
+	}
+
+	return web.Respond(ctx, w, sales, http.StatusOK)
+}
+
+func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	id := web.Param(r, "id")
+
+	sl, err := h.Sale.QueryByID(ctx, claims, id)
+	if err != nil {
+		switch validate.Cause(err) {
+		case database.ErrInvalidID:
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		case database.ErrForbidden:
+			return validate.NewRequestError(err, http.StatusForbidden)
+		default:
+			return fmt.Errorf("ID[%s]: %w", id, err)
+		}
+	}
+
+	return web.Respond(ctx, w, sl, http.StatusOK)
+}
+
+func (h Handlers) QueryByUserID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	id := web.Param(r, "id")
+
+	sales, err := h.Sale.QueryByUserID(ctx, claims, id)
+	if err != nil {
+		switch validate.Cause(err) {
+		case database.ErrInvalidID:
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		case database.ErrForbidden:
+			return validate.NewRequestError(err, http.StatusForbidden)
+		default:
+			return fmt.Errorf("userID[%s]: %w", id, err)
+		}
+	}
+
+	return web.Respond(ctx, w, sales, http.StatusOK)
+}
@@ -0,0 +1,74 @@
+// Package signupgrp maintains the group of handlers for user self registration.
+package signupgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Avyukth/service3-clone/business/core/signup"
+	"github.com/Avyukth/service3-clone/business/data/store/user"
+	"github.com/Avyukth/service3-clone/business/sys/sigtoken"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/Avyukth/service3-clone/foundation/web"
+)
+
+type Handlers struct {
+	Signup signup.Core
+}
+
+// Create registers a new unverified user and mails them a verification token.
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var ns signup.NewSignup
+	if err := web.Decode(r, &ns); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	usr, err := h.Signup.Signup(ctx, ns, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, signup.ErrUnknownTenant):
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, user.ErrEmailExists):
+			return validate.NewRequestError(user.ErrEmailExists, http.StatusConflict)
+		}
+		return fmt.Errorf("unable to sign up user[%s]: %w", ns.Email, err)
+	}
+
+	return web.Respond(ctx, w, usr, http.StatusCreated)
+}
+
+// Confirm marks the email address of the user as verified.
+func (h Handlers) Confirm(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var req struct {
+		Token string `json:"token" validate:"required"`
+	}
+	if err := web.Decode(r, &req); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+	if err := validate.Check(req); err != nil {
+		return err
+	}
+
+	if err := h.Signup.Confirm(ctx, req.Token, v.Now); err != nil {
+		switch {
+		case errors.Is(err, sigtoken.ErrInvalid), errors.Is(err, sigtoken.ErrExpired):
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("confirm: %w", err)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
@@ -0,0 +1,181 @@
+// Package tenantgrp maintains the group of handlers for managing tenants.
+package tenantgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	tenantCore "github.com/Avyukth/service3-clone/business/core/tenant"
+	"github.com/Avyukth/service3-clone/business/data/store/tenant"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/Avyukth/service3-clone/foundation/web"
+)
+
+const (
+	defaultRows = 20
+	maxRows     = 100
+)
+
+type Handlers struct {
+	Tenant tenantCore.Core
+}
+
+// Query returns a page of tenants:
+//
+//	GET /v1/tenants?page=1&rows=20
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	pageNumber, rowsPerPage, err := parsePage(r.URL.Query())
+	if err != nil {
+		return validate.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	tnts, err := h.Tenant.Query(ctx, pageNumber, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("unable to query for tenants: %w", err)
+	}
+
+	if tnts == nil {
+		tnts = []tenant.Tenant{}
+	}
+
+	return web.Respond(ctx, w, tnts, http.StatusOK)
+}
+
+func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := web.Param(r, "id")
+
+	tnt, err := h.Tenant.QueryByID(ctx, id)
+	if err != nil {
+		switch validate.Cause(err) {
+		case database.ErrInvalidID:
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", id, err)
+		}
+	}
+
+	return web.Respond(ctx, w, tnt, http.StatusOK)
+}
+
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	var nt tenant.NewTenant
+	if err := web.Decode(r, &nt); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	tnt, err := h.Tenant.Create(ctx, claims, nt, v.Now)
+	if err != nil {
+		switch validate.Cause(err) {
+		case tenant.ErrExists:
+			return validate.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("creating new tenant, nt[%+v]: %w", nt, err)
+		}
+	}
+
+	return web.Respond(ctx, w, tnt, http.StatusCreated)
+}
+
+// Update renames a tenant or suspends it by setting active to false.
+func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	var ut tenant.UpdateTenant
+	if err := web.Decode(r, &ut); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	id := web.Param(r, "id")
+	if err := h.Tenant.Update(ctx, claims, id, ut, v.Now); err != nil {
+		switch validate.Cause(err) {
+		case database.ErrInvalidID:
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		case tenant.ErrExists, tenant.ErrDefault:
+			return validate.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("ID[%s] Tenant[%+v]: %w", id, &ut, err)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Delete removes a tenant. Tenants that still own users, products or sales
+// are not removed, they can be suspended instead.
+func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	id := web.Param(r, "id")
+	if err := h.Tenant.Delete(ctx, claims, id, v.Now); err != nil {
+		switch validate.Cause(err) {
+		case database.ErrInvalidID:
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		case tenant.ErrInUse, tenant.ErrDefault:
+			return validate.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("ID[%s]: %w", id, err)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+func parsePage(qs url.Values) (int, int, error) {
+	pageNumber := 1
+	if page := qs.Get("page"); page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("invalid page format [%s]", page)
+		}
+		pageNumber = n
+	}
+
+	rowsPerPage := defaultRows
+	if rows := qs.Get("rows"); rows != "" {
+		n, err := strconv.Atoi(rows)
+		if err != nil || n < 1 || n > maxRows {
+			return 0, 0, fmt.Errorf("invalid rows format [%s]", rows)
+		}
+		rowsPerPage = n
+	}
+
+	return pageNumber, rowsPerPage, nil
+}
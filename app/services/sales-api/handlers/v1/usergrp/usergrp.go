@@ -4,10 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	userCore "github.com/Avyukth/service3-clone/business/core/user"
+	"github.com/Avyukth/service3-clone/business/data/order"
+	"github.com/Avyukth/service3-clone/business/data/store/token"
 	"github.com/Avyukth/service3-clone/business/data/store/user"
 	"github.com/Avyukth/service3-clone/business/sys/auth"
 	"github.com/Avyukth/service3-clone/business/sys/database"
@@ -21,25 +27,77 @@ type Handlers struct {
 	Auth *auth.Auth
 }
 
+// Query returns a page of users. Filtering, ordering and the keyset cursor are
+// all taken from the query string:
+//
+//	GET /v1/users?name=go&role=ADMIN&order_by=name,DESC&rows=20&cursor=<next_cursor>
 func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-	page := web.Param(r, "page")
-	pageNumber, err := strconv.Atoi(page)
+	return h.query(ctx, w, r, false)
+}
+
+// QueryDeleted returns a page of soft deleted users. It takes the same query
+// string as Query.
+func (h Handlers) QueryDeleted(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return h.query(ctx, w, r, true)
+}
+
+func (h Handlers) query(ctx context.Context, w http.ResponseWriter, r *http.Request, deleted bool) error {
+	qs := r.URL.Query()
 
+	filter, err := parseFilter(qs)
 	if err != nil {
-		return validate.NewRequestError(fmt.Errorf("invalid page format: [%s]", page), http.StatusBadRequest)
+		return validate.NewRequestError(err, http.StatusBadRequest)
+	}
+	filter.Deleted = deleted
+
+	orderBy, err := order.Parse(qs.Get("order_by"), user.OrderByFields, user.DefaultOrderBy)
+	if err != nil {
+		return validate.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	rowsPerPage := defaultRows
+	if rows := qs.Get("rows"); rows != "" {
+		rowsPerPage, err = strconv.Atoi(rows)
+		if err != nil || rowsPerPage < 1 || rowsPerPage > maxRows {
+			return validate.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+		}
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
 	}
-	rows := web.Param(r, "rows")
-	rowsPerPage, err := strconv.Atoi(rows)
 
+	users, next, err := h.User.Query(ctx, claims, filter, orderBy, qs.Get("cursor"), rowsPerPage)
 	if err != nil {
-		return validate.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+		switch validate.Cause(err) {
+		case database.ErrInvalidCursor:
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("unable to query for users : %w", err)
+		}
 	}
-	users, err := h.User.Query(ctx, pageNumber, rowsPerPage)
+
+	total, err := h.User.Count(ctx, claims, filter)
 	if err != nil {
-		return fmt.Errorf("unable to query for users : %w", err)
+		return fmt.Errorf("unable to count users : %w", err)
+	}
+
+	if users == nil {
+		users = []user.User{}
 	}
 
-	return web.Respond(ctx, w, users, http.StatusOK)
+	resp := struct {
+		Items      []user.User `json:"items"`
+		Total      int         `json:"total"`
+		NextCursor string      `json:"next_cursor,omitempty"`
+	}{
+		Items:      users,
+		Total:      total,
+		NextCursor: next,
+	}
+
+	return web.Respond(ctx, w, resp, http.StatusOK)
 }
 
 func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
@@ -67,6 +125,7 @@ func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.
 
 	}
 
+	w.Header().Set("ETag", etag(usr.Version))
 	return web.Respond(ctx, w, usr, http.StatusOK)
 }
 
@@ -77,14 +136,26 @@ func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return web.NewShutdownError("web value missing from context")
 	}
 
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
 	var nu user.NewUser
 
 	if err := web.Decode(r, &nu); err != nil {
 		return fmt.Errorf("unable to decode payload: %w", err)
 	}
-	usr, err := h.User.Create(ctx, nu, v.Now)
+	usr, err := h.User.Create(ctx, claims, nu, v.Now)
 	if err != nil {
-		return fmt.Errorf("unable to create user[%+v]: %w", &usr, err)
+		switch validate.Cause(err) {
+		case database.ErrForbidden:
+			return validate.NewRequestError(err, http.StatusForbidden)
+		case user.ErrEmailExists:
+			return validate.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("unable to create user[%+v]: %w", &usr, err)
+		}
 	}
 
 	return web.Respond(ctx, w, usr, http.StatusCreated)
@@ -105,14 +176,24 @@ func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return fmt.Errorf("unable to decode payload: %w", err)
 	}
 	id := web.Param(r, "id")
-	if err := h.User.Update(ctx, claims, id, upd, v.Now); err != nil {
+
+	version, err := ifMatch(r, true)
+	if err != nil {
+		return err
+	}
+
+	if err := h.User.Update(ctx, claims, id, upd, version, v.Now); err != nil {
 		switch validate.Cause(err) {
+		case database.ErrVersionConflict:
+			return validate.NewRequestError(err, http.StatusPreconditionFailed)
 		case database.ErrInvalidID:
 			return validate.NewRequestError(err, http.StatusBadRequest)
 		case database.ErrNotFound:
 			return validate.NewRequestError(err, http.StatusNotFound)
 		case database.ErrForbidden:
 			return validate.NewRequestError(err, http.StatusForbidden)
+		case user.ErrEmailExists:
+			return validate.NewRequestError(err, http.StatusConflict)
 		default:
 			return fmt.Errorf("ID[%s] User[%+v]: %w", id, &upd, err)
 		}
@@ -122,7 +203,101 @@ func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 
 }
 
+// QueryMe returns the account of the calling user.
+func (h Handlers) QueryMe(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	usr, err := h.User.QueryById(ctx, claims, claims.Subject)
+	if err != nil {
+		switch validate.Cause(err) {
+		case database.ErrInvalidID, database.ErrNotFound:
+			return validate.NewRequestError(errors.New("token does not belong to a user"), http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", claims.Subject, err)
+		}
+	}
+
+	w.Header().Set("ETag", etag(usr.Version))
+	return web.Respond(ctx, w, usr, http.StatusOK)
+}
+
+// UpdateMe changes the name or email of the calling user. Roles can not be
+// changed this way.
+func (h Handlers) UpdateMe(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	var up user.UpdateProfile
+	if err := web.Decode(r, &up); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	version, err := ifMatch(r, false)
+	if err != nil {
+		return err
+	}
+
+	if err := h.User.UpdateProfile(ctx, claims, up, version, v.Now); err != nil {
+		switch validate.Cause(err) {
+		case database.ErrVersionConflict:
+			return validate.NewRequestError(err, http.StatusPreconditionFailed)
+		case database.ErrInvalidID, database.ErrNotFound:
+			return validate.NewRequestError(errors.New("token does not belong to a user"), http.StatusNotFound)
+		case user.ErrEmailExists:
+			return validate.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("ID[%s] Profile[%+v]: %w", claims.Subject, &up, err)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// ChangePassword changes the password of the calling user. The current
+// password must be provided.
+func (h Handlers) ChangePassword(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	var cp user.ChangePassword
+	if err := web.Decode(r, &cp); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	if err := h.User.ChangePassword(ctx, claims, cp, v.Now); err != nil {
+		switch validate.Cause(err) {
+		case database.ErrAuthenticationFailure:
+			return validate.NewRequestError(errors.New("current password is incorrect"), http.StatusForbidden)
+		case database.ErrInvalidID, database.ErrNotFound:
+			return validate.NewRequestError(errors.New("token does not belong to a user"), http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", claims.Subject, err)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
 func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
 	claims, err := auth.GetClaims(ctx)
 	if err != nil {
 		return errors.New("claims missing in context")
@@ -130,8 +305,15 @@ func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Req
 
 	id := web.Param(r, "id")
 
-	if err := h.User.Delete(ctx, claims, id); err != nil {
+	version, err := ifMatch(r, true)
+	if err != nil {
+		return err
+	}
+
+	if err := h.User.Delete(ctx, claims, id, version, v.Now); err != nil {
 		switch validate.Cause(err) {
+		case database.ErrVersionConflict:
+			return validate.NewRequestError(err, http.StatusPreconditionFailed)
 		case database.ErrInvalidID:
 			return validate.NewRequestError(err, http.StatusBadRequest)
 		case database.ErrNotFound:
@@ -145,6 +327,68 @@ func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Req
 	return web.Respond(ctx, w, nil, http.StatusNoContent)
 }
 
+// Restore brings back a soft deleted user.
+func (h Handlers) Restore(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	id := web.Param(r, "id")
+
+	if err := h.User.Restore(ctx, claims, id, v.Now); err != nil {
+		switch validate.Cause(err) {
+		case database.ErrInvalidID:
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		case database.ErrForbidden:
+			return validate.NewRequestError(err, http.StatusForbidden)
+		default:
+			return fmt.Errorf("ID[%s]: %w", id, err)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Purge permanently removes users that were deleted longer ago than the
+// retention window.
+func (h Handlers) Purge(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	purged, err := h.User.Purge(ctx, claims, v.Now)
+	if err != nil {
+		switch validate.Cause(err) {
+		case database.ErrForbidden:
+			return validate.NewRequestError(err, http.StatusForbidden)
+		default:
+			return fmt.Errorf("purging users: %w", err)
+		}
+	}
+
+	resp := struct {
+		Purged int `json:"purged"`
+	}{
+		Purged: purged,
+	}
+
+	return web.Respond(ctx, w, resp, http.StatusOK)
+}
+
 func (h Handlers) Token(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	v, err := web.GetValues(ctx)
 	if err != nil {
@@ -159,24 +403,190 @@ func (h Handlers) Token(ctx context.Context, w http.ResponseWriter, r *http.Requ
 
 	claims, err := h.User.Authenticate(ctx, v.Now, email, pass)
 	if err != nil {
+		var locked *user.LockedError
+		if errors.As(err, &locked) {
+			retry := int(math.Ceil(locked.Until.Sub(v.Now).Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retry))
+			return validate.NewRequestError(locked, http.StatusLocked)
+		}
+
 		switch validate.Cause(err) {
 		case database.ErrNotFound:
 			return validate.NewRequestError(err, http.StatusNotFound)
 		case database.ErrAuthenticationFailure:
 			return validate.NewRequestError(err, http.StatusUnauthorized)
+		case user.ErrUnverified:
+			return validate.NewRequestError(err, http.StatusForbidden)
 		default:
 			return fmt.Errorf("email[%s]: %w", email, err)
 		}
 	}
 
-	var tkn struct {
-		Token string `json:"token"`
+	refresh, err := h.User.NewRefreshToken(ctx, claims, v.Now)
+	if err != nil {
+		return fmt.Errorf("unable to generate refresh token: %w", err)
+	}
+
+	return h.respondTokens(ctx, w, claims, refresh)
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token pair.
+// Presenting an already used refresh token revokes the whole login session.
+func (h Handlers) Refresh(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var req struct {
+		RefreshToken string `json:"refresh_token" validate:"required"`
+	}
+	if err := web.Decode(r, &req); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+	if err := validate.Check(req); err != nil {
+		return err
+	}
+
+	claims, refresh, err := h.User.Refresh(ctx, req.RefreshToken, v.Now)
+	if err != nil {
+		switch validate.Cause(err) {
+		case database.ErrAuthenticationFailure, token.ErrExpired, token.ErrReused, database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusUnauthorized)
+		default:
+			return fmt.Errorf("refreshing token: %w", err)
+		}
+	}
+
+	return h.respondTokens(ctx, w, claims, refresh)
+}
+
+// Revoke logs the caller out by revoking the access token used for the request
+// and, when provided, the refresh token family it belongs to.
+func (h Handlers) Revoke(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return errors.New("claims missing in context")
+	}
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if r.ContentLength != 0 {
+		if err := web.Decode(r, &req); err != nil {
+			return fmt.Errorf("unable to decode payload: %w", err)
+		}
+	}
+
+	if err := h.User.Revoke(ctx, claims, req.RefreshToken, v.Now); err != nil {
+		switch validate.Cause(err) {
+		case database.ErrAuthenticationFailure:
+			return validate.NewRequestError(err, http.StatusUnauthorized)
+		case database.ErrForbidden:
+			return validate.NewRequestError(err, http.StatusForbidden)
+		default:
+			return fmt.Errorf("revoking token: %w", err)
+		}
 	}
 
-	tkn.Token, err = h.Auth.GenerateToken(claims)
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+type tokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
 
+func (h Handlers) respondTokens(ctx context.Context, w http.ResponseWriter, claims auth.Claims, refresh string) error {
+	tkn, err := h.Auth.GenerateToken(claims)
 	if err != nil {
 		return fmt.Errorf("unable to generate token: %w", err)
 	}
-	return web.Respond(ctx, w, tkn, http.StatusOK)
+
+	resp := tokenResponse{
+		Token:        tkn,
+		RefreshToken: refresh,
+	}
+	return web.Respond(ctx, w, resp, http.StatusOK)
+}
+
+const (
+	defaultRows = 20
+	maxRows     = 100
+)
+
+func parseFilter(qs url.Values) (user.QueryFilter, error) {
+	var filter user.QueryFilter
+
+	if name := qs.Get("name"); name != "" {
+		filter.Name = &name
+	}
+
+	if email := qs.Get("email"); email != "" {
+		filter.Email = &email
+	}
+
+	if role := qs.Get("role"); role != "" {
+		rl, err := auth.ParseRole(role)
+		if err != nil {
+			return user.QueryFilter{}, fmt.Errorf("invalid role [%s]", role)
+		}
+		filter.Role = &rl
+	}
+
+	if start := qs.Get("start_created_date"); start != "" {
+		t, err := time.Parse(time.RFC3339, start)
+		if err != nil {
+			return user.QueryFilter{}, fmt.Errorf("invalid start_created_date [%s]", start)
+		}
+		filter.StartCreatedDate = &t
+	}
+
+	if end := qs.Get("end_created_date"); end != "" {
+		t, err := time.Parse(time.RFC3339, end)
+		if err != nil {
+			return user.QueryFilter{}, fmt.Errorf("invalid end_created_date [%s]", end)
+		}
+		filter.EndCreatedDate = &t
+	}
+
+	return filter, nil
+}
+
+// etag formats the version of a user as a strong entity tag.
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ifMatch returns the version the client expects from the If-Match header.
+// A wildcard, or a missing header when it is not required, skips the check.
+func ifMatch(r *http.Request, required bool) (int, error) {
+	v := strings.TrimSpace(r.Header.Get("If-Match"))
+
+	switch v {
+	case "":
+		if required {
+			return 0, validate.NewRequestError(errors.New("If-Match header with the ETag of the user is required"), http.StatusPreconditionRequired)
+		}
+		return user.AnyVersion, nil
+	case "*":
+		return user.AnyVersion, nil
+	}
+
+	unquoted, err := strconv.Unquote(v)
+	if err != nil {
+		return 0, validate.NewRequestError(fmt.Errorf("invalid If-Match header [%s]", v), http.StatusBadRequest)
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, validate.NewRequestError(fmt.Errorf("invalid If-Match header [%s]", v), http.StatusBadRequest)
+	}
+
+	return version, nil
 }
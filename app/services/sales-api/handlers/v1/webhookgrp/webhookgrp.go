@@ -0,0 +1,214 @@
+// Package webhookgrp maintains the group of handlers for managing webhooks.
+package webhookgrp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	webhookCore "github.com/Avyukth/service3-clone/business/core/webhook"
+	"github.com/Avyukth/service3-clone/business/data/store/webhook"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/Avyukth/service3-clone/foundation/web"
+)
+
+const (
+	defaultRows = 20
+	maxRows     = 100
+)
+
+type Handlers struct {
+	Webhook webhookCore.Core
+}
+
+// Query returns a page of webhooks:
+//
+//	GET /v1/webhooks?page=1&rows=20
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	pageNumber, rowsPerPage, err := parsePage(r.URL.Query())
+	if err != nil {
+		return validate.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	whs, err := h.Webhook.Query(ctx, pageNumber, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("unable to query for webhooks: %w", err)
+	}
+
+	if whs == nil {
+		whs = []webhook.Webhook{}
+	}
+
+	return web.Respond(ctx, w, whs, http.StatusOK)
+}
+
+func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := web.Param(r, "id")
+
+	wh, err := h.Webhook.QueryByID(ctx, id)
+	if err != nil {
+		switch validate.Cause(err) {
+		case database.ErrInvalidID:
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", id, err)
+		}
+	}
+
+	return web.Respond(ctx, w, wh, http.StatusOK)
+}
+
+// Create registers a webhook. The response holds the signing secret, which
+// is not shown again.
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var nw webhook.NewWebhook
+	if err := web.Decode(r, &nw); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	wh, err := h.Webhook.Create(ctx, nw, v.Now)
+	if err != nil {
+		return fmt.Errorf("creating new webhook, nw[%+v]: %w", nw, err)
+	}
+
+	return web.Respond(ctx, w, wh, http.StatusCreated)
+}
+
+func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var uw webhook.UpdateWebhook
+	if err := web.Decode(r, &uw); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	id := web.Param(r, "id")
+	if err := h.Webhook.Update(ctx, id, uw, v.Now); err != nil {
+		switch validate.Cause(err) {
+		case database.ErrInvalidID:
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s] Webhook[%+v]: %w", id, &uw, err)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := web.Param(r, "id")
+
+	if err := h.Webhook.Delete(ctx, id); err != nil {
+		switch validate.Cause(err) {
+		case database.ErrInvalidID:
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", id, err)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// QueryDeliveries returns a page of the delivery history of a webhook, newest
+// first:
+//
+//	GET /v1/webhooks/:id/deliveries?status=dead&page=1&rows=20
+func (h Handlers) QueryDeliveries(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	qs := r.URL.Query()
+
+	pageNumber, rowsPerPage, err := parsePage(qs)
+	if err != nil {
+		return validate.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	status := qs.Get("status")
+	switch status {
+	case "", webhook.StatusPending, webhook.StatusDelivered, webhook.StatusDead:
+	default:
+		return validate.NewRequestError(fmt.Errorf("invalid status [%s]", status), http.StatusBadRequest)
+	}
+
+	id := web.Param(r, "id")
+
+	dls, err := h.Webhook.QueryDeliveries(ctx, id, status, pageNumber, rowsPerPage)
+	if err != nil {
+		switch validate.Cause(err) {
+		case database.ErrInvalidID:
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", id, err)
+		}
+	}
+
+	if dls == nil {
+		dls = []webhook.Delivery{}
+	}
+
+	return web.Respond(ctx, w, dls, http.StatusOK)
+}
+
+// Redeliver queues a delivery to be sent again right away.
+func (h Handlers) Redeliver(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	id := web.Param(r, "id")
+	deliveryID := web.Param(r, "delivery_id")
+
+	if err := h.Webhook.Redeliver(ctx, id, deliveryID, v.Now); err != nil {
+		switch validate.Cause(err) {
+		case database.ErrInvalidID:
+			return validate.NewRequestError(err, http.StatusBadRequest)
+		case database.ErrNotFound:
+			return validate.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s] DeliveryID[%s]: %w", id, deliveryID, err)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusAccepted)
+}
+
+func parsePage(qs url.Values) (int, int, error) {
+	pageNumber := 1
+	if page := qs.Get("page"); page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("invalid page format [%s]", page)
+		}
+		pageNumber = n
+	}
+
+	rowsPerPage := defaultRows
+	if rows := qs.Get("rows"); rows != "" {
+		n, err := strconv.Atoi(rows)
+		if err != nil || n < 1 || n > maxRows {
+			return 0, 0, fmt.Errorf("invalid rows format [%s]", rows)
+		}
+		rowsPerPage = n
+	}
+
+	return pageNumber, rowsPerPage, nil
+}
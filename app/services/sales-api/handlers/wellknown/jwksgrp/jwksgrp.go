@@ -0,0 +1,26 @@
+package jwksgrp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/foundation/web"
+)
+
+type Handlers struct {
+	Auth *auth.Auth
+}
+
+// JWKS publishes the public half of every signing key so other services can
+// verify tokens issued by this service.
+func (h Handlers) JWKS(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	set, err := h.Auth.JWKS()
+	if err != nil {
+		return fmt.Errorf("unable to build key set: %w", err)
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	return web.Respond(ctx, w, set, http.StatusOK)
+}
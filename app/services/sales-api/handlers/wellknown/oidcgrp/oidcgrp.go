@@ -0,0 +1,64 @@
+// Package oidcgrp maintains the group of handlers for OpenID Connect
+// discovery.
+package oidcgrp
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/Avyukth/service3-clone/app/services/sales-api/handlers/oauth/oauthgrp"
+	"github.com/Avyukth/service3-clone/business/data/store/client"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/foundation/web"
+)
+
+type Handlers struct {
+	Issuer string
+}
+
+// configuration is the provider metadata of OpenID Connect Discovery 1.0
+// section 3. The service has no authorization endpoint, so there are no
+// response types and only the grants of the token endpoint are listed.
+type configuration struct {
+	Issuer                            string   `json:"issuer"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	RegistrationEndpoint              string   `json:"registration_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// Discovery publishes the OpenID provider configuration so client libraries
+// can find the endpoints and keys of the service.
+func (h Handlers) Discovery(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	issuer := strings.TrimSuffix(h.Issuer, "/")
+
+	cfg := configuration{
+		Issuer:                 issuer,
+		TokenEndpoint:          issuer + "/oauth/token",
+		UserInfoEndpoint:       issuer + "/userinfo",
+		JWKSURI:                issuer + "/.well-known/jwks.json",
+		RegistrationEndpoint:   issuer + "/oauth/clients",
+		ScopesSupported:        oauthgrp.Scopes,
+		ResponseTypesSupported: []string{},
+		GrantTypesSupported: []string{
+			client.GrantPassword,
+			client.GrantRefreshToken,
+			client.GrantClientCredentials,
+		},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  auth.Algorithms,
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post"},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "name", "email", "email_verified", "tenant_id", "roles"},
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	return web.Respond(ctx, w, cfg, http.StatusOK)
+}
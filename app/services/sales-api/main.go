@@ -5,16 +5,24 @@ import (
 	"errors"
 	"expvar"
 	"fmt"
+	"io/fs"
 	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/Avyukth/service3-clone/app/services/sales-api/handlers"
+	outboxCore "github.com/Avyukth/service3-clone/business/core/outbox"
+	webhookCore "github.com/Avyukth/service3-clone/business/core/webhook"
 	"github.com/Avyukth/service3-clone/business/sys/auth"
 	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/events"
+	"github.com/Avyukth/service3-clone/business/sys/mail"
+	"github.com/Avyukth/service3-clone/business/sys/sigtoken"
 	"github.com/Avyukth/service3-clone/foundation/keystore"
 	"github.com/Avyukth/service3-clone/foundation/logger"
 	"github.com/ardanlabs/conf/v3"
@@ -72,8 +80,26 @@ func run(log *zap.SugaredLogger) error {
 			ShutdownTimeout time.Duration `conf:"default:20s"`
 		}
 		Auth struct {
-			KeysFolder string `conf:"default:zarf/keys"`
-			ActiveKID  string `conf:"default:133d7df7-d74c-4802-985c-f4a64e696f47"`
+			KeysFolder     string        `conf:"default:zarf/keys"`
+			ActiveKID      string        `conf:"default:133d7df7-d74c-4802-985c-f4a64e696f47"`
+			ReloadInterval time.Duration `conf:"default:1m"` // Zero or less only reloads on SIGHUP.
+			GracePeriod    time.Duration `conf:"default:1h"` // How long the public key of a removed key file keeps validating tokens.
+
+			// HMACKey signs tokens sent in email links, like email verification.
+			// There is no default so a deployment can not sign with a known key.
+			HMACKey string `conf:"required,mask"`
+
+			// Issuer is the public URL of the service. It is the issuer of tokens
+			// handed out by the OAuth2 endpoints and the base of the URLs in the
+			// OpenID discovery document.
+			Issuer string `conf:"default:http://localhost:3000"`
+		}
+		Events struct {
+			RelayInterval   time.Duration `conf:"default:1s"` // How often the outbox is checked for events to publish.
+			WebhookInterval time.Duration `conf:"default:5s"` // How often due webhook deliveries are sent.
+		}
+		Mail struct {
+			Folder string // When set every outgoing mail is written to a file in this folder, otherwise it is logged.
 		}
 		DB struct {
 			User         string `conf:"default:postgres"`
@@ -107,6 +133,13 @@ func run(log *zap.SugaredLogger) error {
 		return fmt.Errorf("parse config: %w", err)
 	}
 
+	if cfg.Events.RelayInterval <= 0 {
+		return fmt.Errorf("parse config: events relay interval must be positive, got %v", cfg.Events.RelayInterval)
+	}
+	if cfg.Events.WebhookInterval <= 0 {
+		return fmt.Errorf("parse config: events webhook interval must be positive, got %v", cfg.Events.WebhookInterval)
+	}
+
 	// =================================================================================================================
 	// App starting
 
@@ -138,6 +171,29 @@ func run(log *zap.SugaredLogger) error {
 		return fmt.Errorf("initializing auth: %w", err)
 	}
 
+	// keep the key store in sync with the keys folder so keys can be rotated
+	// without a restart, on a timer or on demand with SIGHUP
+
+	stopKeyReload := startKeyReload(log, ks, auth, cfg.Auth.KeysFolder, cfg.Auth.ReloadInterval, cfg.Auth.GracePeriod)
+	defer stopKeyReload()
+
+	signer, err := sigtoken.New([]byte(cfg.Auth.HMACKey))
+	if err != nil {
+		return fmt.Errorf("initializing link signer: %w", err)
+	}
+
+	// =================================================================================================================
+	// Initialize Mail Support
+
+	var mailer mail.Mailer = mail.NewLogMailer(log)
+	if cfg.Mail.Folder != "" {
+		fm, err := mail.NewFileMailer(cfg.Mail.Folder)
+		if err != nil {
+			return fmt.Errorf("initializing mail: %w", err)
+		}
+		mailer = fm
+	}
+
 	// =================================================================================================================
 	// Initialize Database Support
 
@@ -161,6 +217,25 @@ func run(log *zap.SugaredLogger) error {
 		db.Close()
 	}()
 
+	// =================================================================================================================
+	// Start Event Support
+
+	log.Infow("startup", "status", "initializing event bus, outbox relay and webhook worker")
+
+	bus := events.New(log)
+
+	// Webhook deliveries are queued in the transaction that relays the event
+	// and sent by their own worker, so a slow receiver does not hold up the
+	// outbox.
+	webhooks := webhookCore.NewCore(log, db)
+	bus.SubscribeAll(webhooks.Enqueue)
+
+	stopRelay := startWorker(log, "outbox", cfg.Events.RelayInterval, outboxCore.NewCore(log, db, bus).Relay)
+	defer stopRelay()
+
+	stopWebhooks := startWorker(log, "webhook", cfg.Events.WebhookInterval, webhooks.Deliver)
+	defer stopWebhooks()
+
 	// =================================================================================================================
 	// Start Tracing Support
 
@@ -202,6 +277,9 @@ func run(log *zap.SugaredLogger) error {
 		Auth:     auth,
 		DB:       db,
 		Tracer:   tracer,
+		Mailer:   mailer,
+		Signer:   signer,
+		Issuer:   cfg.Auth.Issuer,
 	})
 
 	api := http.Server{
@@ -241,6 +319,13 @@ func run(log *zap.SugaredLogger) error {
 			api.Close()
 			return fmt.Errorf("could not stop server gracefully: %w", err)
 		}
+
+		// Give subscribers that are still handling events a chance to finish.
+		stopRelay()
+		stopWebhooks()
+		if err := bus.Wait(ctx); err != nil {
+			return fmt.Errorf("could not stop event subscribers gracefully: %w", err)
+		}
 	}
 	return nil
 }
@@ -288,3 +373,119 @@ func startTracing(serviceName string, reporterURI string, probability float64) (
 
 	return traceProvider, nil
 }
+
+// =============================================================================
+
+// activeKIDFile is an optional file in the keys folder naming the key that new
+// tokens are signed with. It overrides the configured active kid.
+const activeKIDFile = "active.kid"
+
+// startKeyReload re-reads the keys folder on every interval and whenever the
+// process receives SIGHUP. An interval of zero or less only reloads on SIGHUP.
+// Removed keys keep validating tokens for the grace period. The returned
+// function stops the reloading.
+func startKeyReload(log *zap.SugaredLogger, ks *keystore.KeyStore, a *auth.Auth, folder string, interval time.Duration, grace time.Duration) func() {
+
+	reload := func() {
+		fsys := os.DirFS(folder)
+
+		active := a.ActiveKID()
+		kid, err := fs.ReadFile(fsys, activeKIDFile)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			log.Errorw("keys", "status", "reading active kid", "ERROR", err)
+			return
+		default:
+			if kid := strings.TrimSpace(string(kid)); kid != "" {
+				active = kid
+			}
+		}
+
+		if err := ks.Reload(fsys, active, grace); err != nil {
+			log.Errorw("keys", "status", "reloading keys", "ERROR", err)
+			return
+		}
+
+		if active != a.ActiveKID() {
+			if err := a.SetActiveKID(active); err != nil {
+				log.Errorw("keys", "status", "switching active kid", "kid", active, "ERROR", err)
+				return
+			}
+			log.Infow("keys", "status", "active kid switched", "kid", active)
+		}
+	}
+
+	reload()
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	// A nil channel never fires, so without an interval only SIGHUP reloads.
+	var tick <-chan time.Time
+	stopTicker := func() {}
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		tick = ticker.C
+		stopTicker = ticker.Stop
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-tick:
+				reload()
+			case <-hup:
+				log.Infow("keys", "status", "reload requested", "signal", "SIGHUP")
+				reload()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(hup)
+		stopTicker()
+		close(done)
+	}
+}
+
+// =============================================================================
+
+// startWorker runs fn on every interval until the returned function is called.
+// The interval must be positive. Stopping waits for a run that is in progress to finish and is safe to do
+// more than once.
+func startWorker(log *zap.SugaredLogger, name string, interval time.Duration, fn func(ctx context.Context, now time.Time) (int, error)) func() {
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval*10)
+				if _, err := fn(ctx, time.Now()); err != nil {
+					log.Errorw(name, "status", "worker run failed", "ERROR", err)
+				}
+				cancel()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+			<-stopped
+		})
+	}
+}
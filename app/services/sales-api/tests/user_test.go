@@ -9,8 +9,10 @@ import (
 	"testing"
 
 	"github.com/Avyukth/service3-clone/app/services/sales-api/handlers"
+	"github.com/Avyukth/service3-clone/business/data/store/apikey"
 	"github.com/Avyukth/service3-clone/business/data/store/user"
 	"github.com/Avyukth/service3-clone/business/data/tests"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
 	"github.com/Avyukth/service3-clone/business/sys/validate"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -49,6 +51,7 @@ func TestUsers(t *testing.T) {
 
 	t.Run("getToken404", tests.getToken404)
 	t.Run("getToken200", tests.getToken200)
+	t.Run("scopedAPIKey403", tests.scopedAPIKey403)
 	// t.Run("postUser400", tests.postUser400)
 	// t.Run("postUser401", tests.postUser401)
 	// t.Run("postUser403", tests.postUser403)
@@ -109,6 +112,65 @@ func (ut *UserTests) getToken200(t *testing.T) {
 
 }
 
+func (ut *UserTests) scopedAPIKey403(t *testing.T) {
+	body, err := json.Marshal(&apikey.NewAPIKey{
+		Name:        "catalog reader",
+		Roles:       []auth.Role{auth.RoleAdmin},
+		Permissions: []auth.Permission{auth.PermProductsRead},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/users/me/apikeys", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	r.Header.Set("Authorization", "Bearer "+ut.adminToken)
+	ut.app.ServeHTTP(w, r)
+
+	t.Log("Given the need to keep API keys to the permissions they are scoped to.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen using an admin key scoped to products:read.", testID)
+		{
+			if w.Code != http.StatusCreated {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 201 for the response : %v", tests.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 201 for the response.", tests.Success, testID)
+
+			var got struct {
+				Key string `json:"key"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unmarshal the response : %v", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to unmarshal the response.", tests.Success, testID)
+
+			r = httptest.NewRequest(http.MethodGet, "/v1/products/1/10", nil)
+			w = httptest.NewRecorder()
+
+			r.Header.Set("X-API-Key", got.Key)
+			ut.app.ServeHTTP(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to query products with the key : %v", tests.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to query products with the key.", tests.Success, testID)
+
+			r = httptest.NewRequest(http.MethodDelete, "/v1/users/c3d59378-3333-4206-777f-9325122678c3", nil)
+			w = httptest.NewRecorder()
+
+			r.Header.Set("X-API-Key", got.Key)
+			ut.app.ServeHTTP(w, r)
+
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 403 deleting a user with the key : %v", tests.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 403 deleting a user with the key.", tests.Success, testID)
+		}
+	}
+}
+
 func (ut *UserTests) postUser400(t *testing.T) {
 	body, err := json.Marshal(&user.NewUser{})
 	if err != nil {
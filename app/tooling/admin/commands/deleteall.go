@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/schema"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+)
+
+// DeleteAll removes every row from the application tables.
+func DeleteAll(cfg database.Config) error {
+	db, err := database.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := schema.DeleteAll(ctx, db); err != nil {
+		return fmt.Errorf("failed to delete data: %w", err)
+	}
+
+	fmt.Println("database data deleted successfully")
+	return nil
+}
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// GenKey creates a new private key in the keys folder, named by a new key id,
+// and writes the public key to stdout. The --alg flag selects rsa (default),
+// ecdsa or ed25519.
+func GenKey(keysFolder string, args []string) error {
+	fs := flag.NewFlagSet("genkey", flag.ContinueOnError)
+	alg := fs.String("alg", "rsa", "key algorithm: rsa, ecdsa or ed25519")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ErrHelp
+		}
+		return err
+	}
+
+	privateKey, block, err := genPrivateKey(*alg)
+	if err != nil {
+		return err
+	}
+
+	kid := uuid.NewString()
+	name := filepath.Join(keysFolder, kid+".pem")
+
+	privateFile, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("creating private file: %w", err)
+	}
+	defer privateFile.Close()
+
+	if err := pem.Encode(privateFile, block); err != nil {
+		return fmt.Errorf("encoding to private file: %w", err)
+	}
+
+	asn1Bytes, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	if err != nil {
+		return fmt.Errorf("marshaling public key: %w", err)
+	}
+
+	publicBlock := pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: asn1Bytes,
+	}
+
+	fmt.Printf("kid: %s\nfile: %s\n\n", kid, name)
+	if err := pem.Encode(os.Stdout, &publicBlock); err != nil {
+		return fmt.Errorf("encoding to public file: %w", err)
+	}
+
+	return nil
+}
+
+// genPrivateKey generates a key for the algorithm along with the PEM block
+// the keystore expects for it.
+func genPrivateKey(alg string) (crypto.Signer, *pem.Block, error) {
+	switch alg {
+	case "rsa":
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, nil, fmt.Errorf("generating rsa key: %w", err)
+		}
+		return key, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}, nil
+
+	case "ecdsa":
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("generating ecdsa key: %w", err)
+		}
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling ecdsa key: %w", err)
+		}
+		return key, &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}, nil
+
+	case "ed25519":
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("generating ed25519 key: %w", err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling ed25519 key: %w", err)
+		}
+		return key, &pem.Block{Type: "PRIVATE KEY", Bytes: der}, nil
+	}
+
+	return nil, nil, fmt.Errorf("unsupported algorithm %q", alg)
+}
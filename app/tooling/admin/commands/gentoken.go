@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/user"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/foundation/keystore"
+	"go.uber.org/zap"
+)
+
+// GenToken generates a JWT for the user with the specified email, signed with
+// the key identified by --kid (the active kid by default).
+func GenToken(log *zap.SugaredLogger, cfg database.Config, keysFolder string, activeKID string, args []string) error {
+	fs := flag.NewFlagSet("gentoken", flag.ContinueOnError)
+	email := fs.String("email", "", "email of the user the token is for")
+	kid := fs.String("kid", activeKID, "id of the key used to sign the token")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ErrHelp
+		}
+		return err
+	}
+
+	if *email == "" {
+		fs.Usage()
+		return ErrHelp
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	store := user.NewStore(log, db)
+
+	usr, err := store.LookupByEmail(ctx, *email)
+	if err != nil {
+		return fmt.Errorf("retrieve user: %w", err)
+	}
+
+	claims, err := store.Claims(ctx, time.Now(), usr.ID)
+	if err != nil {
+		return fmt.Errorf("building claims: %w", err)
+	}
+
+	ks, err := keystore.NewFs(os.DirFS(keysFolder))
+	if err != nil {
+		return fmt.Errorf("reading keys: %w", err)
+	}
+
+	a, err := auth.New(*kid, ks)
+	if err != nil {
+		return fmt.Errorf("constructing auth: %w", err)
+	}
+
+	token, err := a.GenerateToken(claims)
+	if err != nil {
+		return fmt.Errorf("generating token: %w", err)
+	}
+
+	fmt.Printf("-----BEGIN TOKEN-----\n%s\n-----END TOKEN-----\n", token)
+	return nil
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/role"
+	"github.com/Avyukth/service3-clone/business/data/store/tenant"
+	"github.com/Avyukth/service3-clone/business/data/store/user"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"go.uber.org/zap"
+)
+
+// UserAdd adds a new user to the database.
+func UserAdd(log *zap.SugaredLogger, cfg database.Config, args []string) error {
+	fs := flag.NewFlagSet("useradd", flag.ContinueOnError)
+	name := fs.String("name", "", "full name of the user")
+	email := fs.String("email", "", "email of the user")
+	password := fs.String("password", "", "password of the user")
+	roles := fs.String("roles", auth.RoleUser.Name(), "comma separated list of roles")
+	tenantID := fs.String("tenant", tenant.DefaultID, "id of the tenant the user belongs to")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ErrHelp
+		}
+		return err
+	}
+
+	if *name == "" || *email == "" || *password == "" {
+		fs.Usage()
+		return ErrHelp
+	}
+
+	var userRoles []auth.Role
+	for _, r := range strings.Split(*roles, ",") {
+		role, err := auth.ParseRole(strings.TrimSpace(r))
+		if err != nil {
+			return fmt.Errorf("parsing role %q: %w", r, err)
+		}
+		userRoles = append(userRoles, role)
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := role.NewStore(log, db).Check(ctx, userRoles); err != nil {
+		return fmt.Errorf("checking roles: %w", err)
+	}
+
+	store := user.NewStore(log, db)
+
+	nu := user.NewUser{
+		Name:            *name,
+		Email:           *email,
+		Password:        *password,
+		PasswordConfirm: *password,
+		Roles:           userRoles,
+	}
+
+	usr, err := store.Create(ctx, *tenantID, nu, time.Now())
+	if err != nil {
+		return fmt.Errorf("create user: %w", err)
+	}
+
+	fmt.Println("user id:", usr.ID)
+	return nil
+}
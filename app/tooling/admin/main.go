@@ -1,247 +1,148 @@
+// This program performs administrative tasks for the sales service.
 package main
 
 import (
-	"context"
-	// "crypto/rand"
-	// "crypto/rsa"
-	// "crypto/x509"
-	// "encoding/pem"
-	// "errors"
+	"errors"
 	"fmt"
-	// "io"
 	"os"
-	"time"
 
-	"github.com/Avyukth/service3-clone/business/data/schema"
+	"github.com/Avyukth/service3-clone/app/tooling/admin/commands"
 	"github.com/Avyukth/service3-clone/business/sys/database"
-	// "github.com/golang-jwt/jwt/v5"
-	// "github.com/google/uuid"
+	"github.com/Avyukth/service3-clone/foundation/logger"
+	"github.com/ardanlabs/conf/v3"
+	"go.uber.org/zap"
 )
 
-func main() {
-	// err := genToken()
-	err := migrate()
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-}
+var build = "develop"
 
-func seed() error {
-	cfg := database.Config{
-		Host:         "localhost",
-		User:         "postgres",
-		Password:     "postgres",
-		Name:         "postgres",
-		MaxIdleConns: 0,
-		MaxOpenConns: 0,
-		DisableTLS:   true,
+type config struct {
+	conf.Version
+	Args conf.Args
+	DB   struct {
+		User         string `conf:"default:postgres"`
+		Password     string `conf:"default:postgres,mask"`
+		Host         string `conf:"default:localhost"`
+		Name         string `conf:"default:postgres"`
+		MaxIdleConns int    `conf:"default:0"`
+		MaxOpenConns int    `conf:"default:0"`
+		DisableTLS   bool   `conf:"default:true"`
 	}
-
-	db, err := database.Open(cfg)
-	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+	Auth struct {
+		KeysFolder string `conf:"default:zarf/keys"`
+		ActiveKID  string `conf:"default:133d7df7-d74c-4802-985c-f4a64e696f47"`
 	}
-	defer db.Close()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := schema.Seed(ctx, db); err != nil {
-		return fmt.Errorf("failed to migrate database: %w", err)
-	}
-	fmt.Println("database seeding successfully")
-	return nil
 }
 
-func migrate() error {
-
-	cfg := database.Config{
-		Host:         "localhost",
-		User:         "postgres",
-		Password:     "postgres",
-		Name:         "postgres",
-		MaxIdleConns: 0,
-		MaxOpenConns: 0,
-		DisableTLS:   true,
-	}
-
-	db, err := database.Open(cfg)
+func main() {
+	log, err := logger.New("ADMIN")
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		fmt.Println(err)
+		os.Exit(1)
 	}
-	defer db.Close()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	defer log.Sync()
 
-	if err := schema.Migrate(ctx, db); err != nil {
-		return fmt.Errorf("failed to migrate database: %w", err)
+	if err := run(log); err != nil {
+		if !errors.Is(err, commands.ErrHelp) {
+			fmt.Println("msg", err)
+		}
+		os.Exit(1)
 	}
-	fmt.Println("database migrated successfully")
-
-	return seed()
 }
 
-/*
-
-func genToken() error {
-	//	==================================================================================
-	// Get private key file from Disk.
-
-	// name, kid, err := genPrivateKey()
-	name := "zarf/keys/133d7df7-d74c-4802-985c-f4a64e696f47.pem"
-	kid := "133d7df7-d74c-4802-985c-f4a64e696f47"
-	// if err != nil {
-	// 	return fmt.Errorf("error getting private key name: %w", err)
-	// }
-
-	// file, err := os.Open(name)
-	// if err != nil {
-	// 	return err
-	// }
-
-	privateKeyFile, err := os.OpenFile(name, os.O_RDONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("creating private.pem file: %w", err)
-	}
-
-	// defer privateKeyFile.Close()
-
-	privatePEM, err := io.ReadAll(io.LimitReader(privateKeyFile, 1024*1024))
-
-	if err != nil {
-		return fmt.Errorf("reading auth private key: %w", err)
-	}
-
-	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
-	if err != nil {
-		return fmt.Errorf("parsing auth private key: %w", err)
-	}
-
-	fmt.Println("================================================================== private Key")
-	fmt.Println(string(privatePEM))
-	fmt.Println("==================================================================")
-
-	// ============================================================================================================================
-	// generate token from claim
-
-	claims := struct {
-		jwt.RegisteredClaims `json:"registered"`
-		Roles                []string `json:"roles"`
-	}{
-		jwt.RegisteredClaims{
-			// A usual scenario is to set the expiration time relative to the current time
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "service Project",
-			Subject:   "123456789",
-			ID:        "1",
-			Audience:  []string{"somebody_else"},
+func run(log *zap.SugaredLogger) error {
+	cfg := config{
+		Version: conf.Version{
+			Build: build,
+			Desc:  "Admin tooling for the sales-api service",
 		},
-		[]string{"ADMIN"},
 	}
-	method := jwt.SigningMethodRS256
-	token := jwt.NewWithClaims(method, claims)
-	token.Header["kid"] = kid
-
-	tokenStr, err := token.SignedString(privateKey)
-	if err != nil {
-		return fmt.Errorf("signing token: %w", err)
-	}
-
-	fmt.Println("==================== TOKEN STARTED ======================")
-	fmt.Println(tokenStr)
-
-	fmt.Println("==================== TOKEN ENDED ======================")
-
-	fmt.Println("\n")
 
-	// =================================================================================================================
-	// write to standard out the public key
-
-	asn1Bytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	// Settings are read with the same prefix as sales-api so both binaries
+	// share one set of environment variables.
+	const prefix = "SALES"
 
+	help, err := conf.Parse(prefix, &cfg)
 	if err != nil {
-		return fmt.Errorf("marshaling public key: %w", err)
+		if errors.Is(err, conf.ErrHelpWanted) {
+			fmt.Println(help)
+			printUsage()
+			return nil
+		}
+		return fmt.Errorf("parsing config: %w", err)
 	}
 
-	publicBlock := pem.Block{
-		Type:  "RSA PUBLIC KEY",
-		Bytes: asn1Bytes,
+	return processCommands(cfg.Args, log, cfg)
+}
+
+// processCommands handles the execution of the commands specified on
+// the command line.
+func processCommands(args conf.Args, log *zap.SugaredLogger, cfg config) error {
+	dbConfig := database.Config{
+		User:         cfg.DB.User,
+		Password:     cfg.DB.Password,
+		Host:         cfg.DB.Host,
+		Name:         cfg.DB.Name,
+		MaxIdleConns: cfg.DB.MaxIdleConns,
+		MaxOpenConns: cfg.DB.MaxOpenConns,
+		DisableTLS:   cfg.DB.DisableTLS,
 	}
 
-	if err := pem.Encode(os.Stdout, &publicBlock); err != nil {
-		return fmt.Errorf("encoding to public file: %v", err)
+	var rest []string
+	if len(args) > 1 {
+		rest = args[1:]
 	}
 
-	fmt.Println("private and public keys generated successfully")
-	// =================================================================================================================
+	switch args.Num(0) {
+	case "migrate":
+		if err := commands.Migrate(dbConfig); err != nil {
+			return fmt.Errorf("migrating database: %w", err)
+		}
 
-	parser := &jwt.Parser{}
+	case "seed":
+		if err := commands.Seed(dbConfig); err != nil {
+			return fmt.Errorf("seeding database: %w", err)
+		}
 
-	keyFunc := func(t *jwt.Token) (interface{}, error) {
-		kid, ok := t.Header["kid"]
-		if !ok {
-			return nil, errors.New("missing key id (kid) in token header ")
+	case "delete-all":
+		if err := commands.DeleteAll(dbConfig); err != nil {
+			return fmt.Errorf("deleting data: %w", err)
 		}
 
-		kidID, ok := kid.(string)
-		if !ok {
-			return nil, errors.New("user token key id (kid) must be string")
+	case "genkey":
+		if err := commands.GenKey(cfg.Auth.KeysFolder, rest); err != nil {
+			return fmt.Errorf("key generation: %w", err)
+		}
 
+	case "gentoken":
+		if err := commands.GenToken(log, dbConfig, cfg.Auth.KeysFolder, cfg.Auth.ActiveKID, rest); err != nil {
+			return fmt.Errorf("generating token: %w", err)
 		}
-		fmt.Println("========== kid:", kidID)
 
-		return &privateKey.PublicKey, nil
-	}
+	case "useradd":
+		if err := commands.UserAdd(log, dbConfig, rest); err != nil {
+			return fmt.Errorf("adding user: %w", err)
+		}
 
-	var parsedClaims struct {
-		jwt.RegisteredClaims `json:"registered"`
-		Roles                []string `json:"roles"`
-	}
+	case "version":
+		fmt.Println(cfg.Version.Build)
 
-	parsedToken, err := parser.ParseWithClaims(tokenStr, &parsedClaims, keyFunc)
-	if err != nil {
-		return fmt.Errorf("parsing token: %w", err)
-	}
-	if !parsedToken.Valid {
-		return fmt.Errorf("token is not valid")
+	default:
+		printUsage()
+		return commands.ErrHelp
 	}
 
-	fmt.Println("========== Token validated")
 	return nil
 }
 
-func genPrivateKey() (string, string, error) {
-
-	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
-
-	if err != nil {
-		return "", "", err
-	}
-	kid := uuid.New().String()
-	name := "zarf/keys/" + kid + ".pem"
-	privateKeyFile, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
-	if err != nil {
-		return "", "", fmt.Errorf("creating private.pem file: %w", err)
-	}
-
-	defer privateKeyFile.Close()
-
-	privateBlock := pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
-	}
-
-	if err := pem.Encode(privateKeyFile, &privateBlock); err != nil {
-		return "", "", fmt.Errorf("encoding to private file: %v", err)
-	}
-	fmt.Println("==================================pem generate")
-	return name, kid, nil
-	// ============================================================================================================================
-
+func printUsage() {
+	fmt.Println("Usage: admin [options] <command> [command options]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  migrate     create the schema in the database")
+	fmt.Println("  seed        add data to the database")
+	fmt.Println("  delete-all  remove all data from the database")
+	fmt.Println("  genkey      generate a new private key [--alg rsa|ecdsa|ed25519]")
+	fmt.Println("  gentoken    generate a token for a user --email <email> [--kid <kid>]")
+	fmt.Println("  useradd     add a user --name <name> --email <email> --password <password> [--roles ADMIN,USER]")
+	fmt.Println("  version     print the build version")
 }
-
-*/
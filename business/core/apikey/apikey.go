@@ -0,0 +1,163 @@
+// Package apikey provides the business logic for API keys, which let machine
+// clients call the service on behalf of a user without a password.
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/apikey"
+	"github.com/Avyukth/service3-clone/business/data/store/audit"
+	"github.com/Avyukth/service3-clone/business/data/store/user"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Core struct {
+	log    *zap.SugaredLogger
+	db     *sqlx.DB
+	apikey apikey.Store
+	user   user.Store
+	audit  audit.Store
+}
+
+func NewCore(log *zap.SugaredLogger, db *sqlx.DB) Core {
+	return Core{
+		log:    log,
+		db:     db,
+		apikey: apikey.NewStore(log, db),
+		user:   user.NewStore(log, db),
+		audit:  audit.NewStore(log, db),
+	}
+}
+
+// Created is a new API key together with the raw key.
+type Created struct {
+	apikey.APIKey
+	Key string `json:"key"`
+}
+
+// Create issues an API key for the user of the claims. The raw key is only
+// returned here.
+func (c Core) Create(ctx context.Context, claims auth.Claims, nk apikey.NewAPIKey, now time.Time) (Created, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	var key apikey.APIKey
+	var raw string
+	f := func(ctx context.Context) error {
+		var err error
+		key, raw, err = c.apikey.Create(ctx, claims, nk, now)
+		if err != nil {
+			return err
+		}
+		return c.audit.Record(ctx, claims, audit.EntityAPIKey, key.ID, audit.ActionCreate, nil, key, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return Created{}, fmt.Errorf("create api key failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return Created{APIKey: key, Key: raw}, nil
+}
+
+func (c Core) QueryByUserID(ctx context.Context, claims auth.Claims, userID string) ([]apikey.APIKey, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	keys, err := c.apikey.QueryByUserID(ctx, claims, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query api keys failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return keys, nil
+}
+
+func (c Core) Revoke(ctx context.Context, claims auth.Claims, userID string, keyID string, now time.Time) error {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	f := func(ctx context.Context) error {
+		before, err := c.apikey.Revoke(ctx, claims, userID, keyID, now)
+		if err != nil {
+			return err
+		}
+		return c.audit.Record(ctx, claims, audit.EntityAPIKey, keyID, audit.ActionRevoke, before, nil, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return fmt.Errorf("revoke api key failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return nil
+}
+
+// ResolveAPIKey implements auth.APIKeyResolver. The claims are those of the
+// owner of the key, with only the roles the key was scoped to that the owner
+// still holds. A deleted owner or a suspended tenant disables the key.
+func (c Core) ResolveAPIKey(ctx context.Context, raw string) (auth.Claims, error) {
+	if !strings.HasPrefix(raw, apikey.Prefix+"_") {
+		return auth.Claims{}, auth.ErrInvalidAPIKey
+	}
+
+	now := time.Now().UTC()
+
+	key, err := c.apikey.Authenticate(ctx, raw, now)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) || errors.Is(err, apikey.ErrExpired) {
+			return auth.Claims{}, auth.ErrInvalidAPIKey
+		}
+		return auth.Claims{}, fmt.Errorf("resolve api key failed: %w", err)
+	}
+
+	claims, err := c.user.Claims(ctx, now, key.UserID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return auth.Claims{}, auth.ErrInvalidAPIKey
+		}
+		return auth.Claims{}, fmt.Errorf("resolve api key failed: %w", err)
+	}
+
+	var roles []auth.Role
+	for _, name := range key.Roles {
+		role, err := auth.ParseRole(name)
+		if err != nil {
+			return auth.Claims{}, fmt.Errorf("resolve api key failed: %w", err)
+		}
+		if claims.Authorized(role) {
+			roles = append(roles, role)
+		}
+	}
+	if len(roles) == 0 {
+		return auth.Claims{}, auth.ErrInvalidAPIKey
+	}
+
+	perms := make([]auth.Permission, len(key.Permissions))
+	for i, name := range key.Permissions {
+		perm, err := auth.ParsePermission(name)
+		if err != nil {
+			return auth.Claims{}, fmt.Errorf("resolve api key failed: %w", err)
+		}
+		perms[i] = perm
+	}
+
+	claims.ID = key.ID
+	claims.APIKey = true
+	claims.ExpiresAt = nil
+	if key.DateExpires != nil {
+		claims.ExpiresAt = jwt.NewNumericDate(*key.DateExpires)
+	}
+	claims.Roles = roles
+	claims.Permissions = perms
+
+	return claims, nil
+}
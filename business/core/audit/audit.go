@@ -0,0 +1,37 @@
+// Package audit provides the core business API for reading the audit log.
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Avyukth/service3-clone/business/data/store/audit"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Core struct {
+	log   *zap.SugaredLogger
+	audit audit.Store
+}
+
+func NewCore(log *zap.SugaredLogger, db *sqlx.DB) Core {
+	return Core{
+		log:   log,
+		audit: audit.NewStore(log, db),
+	}
+}
+
+func (c Core) Query(ctx context.Context, claims auth.Claims, filter audit.QueryFilter, pageNumber int, rowsPerPage int) ([]audit.Audit, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	adts, err := c.audit.Query(ctx, claims, filter, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return adts, nil
+}
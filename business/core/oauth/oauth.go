@@ -0,0 +1,231 @@
+// Package oauth provides the business logic for the OAuth2 authorization
+// server: registering clients and exchanging grants for token claims.
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	userCore "github.com/Avyukth/service3-clone/business/core/user"
+	"github.com/Avyukth/service3-clone/business/data/store/audit"
+	"github.com/Avyukth/service3-clone/business/data/store/client"
+	"github.com/Avyukth/service3-clone/business/data/store/token"
+	"github.com/Avyukth/service3-clone/business/data/store/user"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Errors a grant can fail with. They match the error codes of RFC 6749.
+var (
+	ErrInvalidClient      = errors.New("client authentication failed")
+	ErrUnauthorizedClient = errors.New("client is not allowed to use this grant type")
+	ErrInvalidGrant       = errors.New("grant is invalid, expired or revoked")
+)
+
+type Core struct {
+	log    *zap.SugaredLogger
+	db     *sqlx.DB
+	client client.Store
+	user   user.Store
+	audit  audit.Store
+	users  userCore.Core
+}
+
+func NewCore(log *zap.SugaredLogger, db *sqlx.DB) Core {
+	return Core{
+		log:    log,
+		db:     db,
+		client: client.NewStore(log, db),
+		user:   user.NewStore(log, db),
+		audit:  audit.NewStore(log, db),
+		users:  userCore.NewCore(log, db),
+	}
+}
+
+// Registered is a new client together with its raw secret.
+type Registered struct {
+	client.Client
+	Secret string `json:"client_secret"`
+}
+
+// RegisterClient registers a client for the user of the claims. The raw
+// secret is only returned here.
+func (c Core) RegisterClient(ctx context.Context, claims auth.Claims, nc client.NewClient, now time.Time) (Registered, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	var clt client.Client
+	var secret string
+	f := func(ctx context.Context) error {
+		var err error
+		clt, secret, err = c.client.Create(ctx, claims, nc, now)
+		if err != nil {
+			return err
+		}
+		return c.audit.Record(ctx, claims, audit.EntityClient, clt.ID, audit.ActionCreate, nil, clt, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return Registered{}, fmt.Errorf("register client failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return Registered{Client: clt, Secret: secret}, nil
+}
+
+func (c Core) QueryClients(ctx context.Context, claims auth.Claims, userID string) ([]client.Client, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	clts, err := c.client.QueryByUserID(ctx, claims, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query clients failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return clts, nil
+}
+
+func (c Core) DeleteClient(ctx context.Context, claims auth.Claims, clientID string, now time.Time) error {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	f := func(ctx context.Context) error {
+		before, err := c.client.Delete(ctx, claims, clientID)
+		if err != nil {
+			return err
+		}
+		return c.audit.Record(ctx, claims, audit.EntityClient, clientID, audit.ActionDelete, before, nil, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return fmt.Errorf("delete client failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return nil
+}
+
+// AuthenticateClient returns the client the credentials belong to.
+func (c Core) AuthenticateClient(ctx context.Context, clientID string, secret string) (client.Client, error) {
+	clt, err := c.client.Authenticate(ctx, clientID, secret)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return client.Client{}, ErrInvalidClient
+		}
+		return client.Client{}, fmt.Errorf("authenticate client failed: %w", err)
+	}
+
+	return clt, nil
+}
+
+// Password runs the resource owner password credentials grant. A refresh
+// token is only issued when the client may use the refresh token grant.
+func (c Core) Password(ctx context.Context, clt client.Client, email string, password string, now time.Time) (auth.Claims, string, error) {
+	if !clt.Allows(client.GrantPassword) {
+		return auth.Claims{}, "", ErrUnauthorizedClient
+	}
+
+	claims, err := c.users.Authenticate(ctx, now, email, password)
+	if err != nil {
+		var locked *user.LockedError
+		if errors.As(err, &locked) {
+			return auth.Claims{}, "", locked
+		}
+
+		switch validate.Cause(err) {
+		case database.ErrNotFound, database.ErrAuthenticationFailure, user.ErrUnverified:
+			return auth.Claims{}, "", ErrInvalidGrant
+		default:
+			return auth.Claims{}, "", fmt.Errorf("password grant failed: %w", err)
+		}
+	}
+
+	// Emails are unique across tenants, a client only logs in its own.
+	if claims.TenantID != clt.TenantID {
+		return auth.Claims{}, "", ErrInvalidGrant
+	}
+
+	if !clt.Allows(client.GrantRefreshToken) {
+		return claims, "", nil
+	}
+
+	refresh, err := c.users.NewRefreshToken(ctx, claims, now)
+	if err != nil {
+		return auth.Claims{}, "", fmt.Errorf("password grant failed: %w", err)
+	}
+
+	return claims, refresh, nil
+}
+
+// Refresh runs the refresh token grant, rotating the refresh token.
+func (c Core) Refresh(ctx context.Context, clt client.Client, refreshToken string, now time.Time) (auth.Claims, string, error) {
+	if !clt.Allows(client.GrantRefreshToken) {
+		return auth.Claims{}, "", ErrUnauthorizedClient
+	}
+
+	claims, refresh, err := c.users.Refresh(ctx, refreshToken, now)
+	if err != nil {
+		switch validate.Cause(err) {
+		case database.ErrNotFound, database.ErrAuthenticationFailure, token.ErrExpired, token.ErrReused:
+			return auth.Claims{}, "", ErrInvalidGrant
+		default:
+			return auth.Claims{}, "", fmt.Errorf("refresh grant failed: %w", err)
+		}
+	}
+
+	if claims.TenantID != clt.TenantID {
+		return auth.Claims{}, "", ErrInvalidGrant
+	}
+
+	return claims, refresh, nil
+}
+
+// ClientCredentials runs the client credentials grant. Like an API key the
+// claims are those of the user that registered the client, with only the
+// roles of the client the user still holds.
+func (c Core) ClientCredentials(ctx context.Context, clt client.Client, now time.Time) (auth.Claims, error) {
+	if !clt.Allows(client.GrantClientCredentials) {
+		return auth.Claims{}, ErrUnauthorizedClient
+	}
+
+	claims, err := c.user.Claims(ctx, now, clt.UserID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return auth.Claims{}, ErrInvalidClient
+		}
+		return auth.Claims{}, fmt.Errorf("client credentials failed: %w", err)
+	}
+
+	var roles []auth.Role
+	for _, name := range clt.Roles {
+		role, err := auth.ParseRole(name)
+		if err != nil {
+			return auth.Claims{}, fmt.Errorf("client credentials failed: %w", err)
+		}
+		if claims.Authorized(role) {
+			roles = append(roles, role)
+		}
+	}
+	if len(roles) == 0 {
+		return auth.Claims{}, ErrUnauthorizedClient
+	}
+	claims.Roles = roles
+
+	return claims, nil
+}
+
+// UserInfo returns the user the claims were issued for.
+func (c Core) UserInfo(ctx context.Context, claims auth.Claims) (user.User, error) {
+	usr, err := c.users.QueryById(ctx, claims, claims.Subject)
+	if err != nil {
+		return user.User{}, fmt.Errorf("user info failed: %w", err)
+	}
+
+	return usr, nil
+}
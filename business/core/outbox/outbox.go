@@ -0,0 +1,78 @@
+// Package outbox relays the events stored in the outbox to the subscribers on
+// the event bus. Delivery is at least once: an event whose publish failed, or
+// whose transaction could not be committed, is published again.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/outbox"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/events"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// batchSize is the maximum number of events published by a single relay.
+const batchSize = 100
+
+type Core struct {
+	log    *zap.SugaredLogger
+	db     *sqlx.DB
+	outbox outbox.Store
+	bus    *events.Bus
+}
+
+func NewCore(log *zap.SugaredLogger, db *sqlx.DB, bus *events.Bus) Core {
+	return Core{
+		log:    log,
+		db:     db,
+		outbox: outbox.NewStore(log, db),
+		bus:    bus,
+	}
+}
+
+// Relay publishes a batch of pending events and returns how many were
+// published. An event that fails to publish stays in the outbox and is retried
+// by a later relay.
+func (c Core) Relay(ctx context.Context, now time.Time) (int, error) {
+
+	var published int
+	f := func(ctx context.Context) error {
+		envs, err := c.outbox.Pending(ctx, batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, env := range envs {
+			// Each event gets a savepoint so a subscriber that fails half way,
+			// or aborts the transaction with a failed query, only undoes its
+			// own work and the failed attempt can still be recorded.
+			publish := func(ctx context.Context) error {
+				if err := c.bus.Publish(ctx, env); err != nil {
+					return err
+				}
+				return c.outbox.MarkPublished(ctx, env.ID, now)
+			}
+
+			if err := database.WithinSavepoint(ctx, c.db, publish); err != nil {
+				c.log.Errorw("outbox", "status", "publish failed", "name", env.Name, "id", env.ID, "ERROR", err)
+				if err := c.outbox.MarkFailed(ctx, env.ID, err); err != nil {
+					return err
+				}
+				continue
+			}
+			published++
+		}
+
+		return nil
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return 0, fmt.Errorf("relay failed: %w", err)
+	}
+
+	return published, nil
+}
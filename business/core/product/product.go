@@ -0,0 +1,141 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/audit"
+	"github.com/Avyukth/service3-clone/business/data/store/product"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Core struct {
+	log     *zap.SugaredLogger
+	db      *sqlx.DB
+	product product.Store
+	audit   audit.Store
+}
+
+func NewCore(log *zap.SugaredLogger, db *sqlx.DB) Core {
+	return Core{
+		log:     log,
+		db:      db,
+		product: product.NewStore(log, db),
+		audit:   audit.NewStore(log, db),
+	}
+}
+
+func (c Core) Create(ctx context.Context, claims auth.Claims, np product.NewProduct, now time.Time) (product.Product, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	var prd product.Product
+	f := func(ctx context.Context) error {
+		var err error
+		prd, err = c.product.Create(ctx, claims, np, now)
+		if err != nil {
+			return err
+		}
+		return c.audit.Record(ctx, claims, audit.EntityProduct, prd.ID, audit.ActionCreate, nil, prd, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return product.Product{}, fmt.Errorf("create product failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return prd, nil
+}
+
+func (c Core) Update(ctx context.Context, claims auth.Claims, productID string, up product.UpdateProduct, now time.Time) error {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	f := func(ctx context.Context) error {
+		before, err := c.product.QueryByID(ctx, claims, productID)
+		if err != nil {
+			return err
+		}
+		if err := c.product.Update(ctx, claims, productID, up, now); err != nil {
+			return err
+		}
+		after, err := c.product.QueryByID(ctx, claims, productID)
+		if err != nil {
+			return err
+		}
+		return c.audit.Record(ctx, claims, audit.EntityProduct, productID, audit.ActionUpdate, before, after, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return fmt.Errorf("update product failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return nil
+}
+
+func (c Core) Delete(ctx context.Context, claims auth.Claims, productID string, now time.Time) error {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	f := func(ctx context.Context) error {
+		before, err := c.product.QueryByID(ctx, claims, productID)
+		if err != nil {
+			return err
+		}
+		if err := c.product.Delete(ctx, claims, productID); err != nil {
+			return err
+		}
+		return c.audit.Record(ctx, claims, audit.EntityProduct, productID, audit.ActionDelete, before, nil, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return fmt.Errorf("delete product failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return nil
+}
+
+func (c Core) Query(ctx context.Context, claims auth.Claims, pageNumber int, rowsPerPage int) ([]product.Product, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	prds, err := c.product.Query(ctx, claims, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return prds, nil
+}
+
+func (c Core) QueryByID(ctx context.Context, claims auth.Claims, productID string) (product.Product, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	prd, err := c.product.QueryByID(ctx, claims, productID)
+	if err != nil {
+		return product.Product{}, fmt.Errorf("query product by id failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return prd, nil
+}
+
+func (c Core) QueryByUserID(ctx context.Context, claims auth.Claims, userID string) ([]product.Product, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	prds, err := c.product.QueryByUserID(ctx, claims, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query products by user id failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return prds, nil
+}
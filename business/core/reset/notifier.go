@@ -0,0 +1,40 @@
+package reset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/user"
+	"github.com/Avyukth/service3-clone/business/sys/mail"
+)
+
+// Notifier delivers a password reset token to the user it was issued for.
+type Notifier interface {
+	NotifyReset(ctx context.Context, usr user.User, token string, expires time.Time) error
+}
+
+// MailNotifier delivers reset tokens by email.
+type MailNotifier struct {
+	mailer mail.Mailer
+}
+
+// NewMailNotifier constructs a Notifier that sends reset tokens with mailer.
+func NewMailNotifier(mailer mail.Mailer) MailNotifier {
+	return MailNotifier{mailer: mailer}
+}
+
+// NotifyReset mails the token to the user.
+func (n MailNotifier) NotifyReset(ctx context.Context, usr user.User, token string, expires time.Time) error {
+	msg := mail.Message{
+		To:      usr.Email,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf("Hi %s,\n\nuse this token to set a new password before %s:\n\n%s\n\nIf you did not ask for this you can ignore this mail.\n", usr.Name, expires.Format(time.RFC1123), token),
+	}
+
+	if err := n.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("sending reset mail: %w", err)
+	}
+
+	return nil
+}
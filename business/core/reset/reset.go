@@ -0,0 +1,113 @@
+// Package reset provides the business logic for users recovering their
+// account by resetting a forgotten password.
+package reset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/audit"
+	"github.com/Avyukth/service3-clone/business/data/store/token"
+	"github.com/Avyukth/service3-clone/business/data/store/user"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// resetTTL is how long a password reset token can be used.
+const resetTTL = time.Hour
+
+// CompleteReset is what a user provides to set a new password.
+type CompleteReset struct {
+	Token           string `json:"token" validate:"required"`
+	Password        string `json:"password" validate:"required"`
+	PasswordConfirm string `json:"password_confirm" validate:"eqfield=Password"`
+}
+
+type Core struct {
+	log      *zap.SugaredLogger
+	db       *sqlx.DB
+	user     user.Store
+	token    token.Store
+	audit    audit.Store
+	notifier Notifier
+}
+
+func NewCore(log *zap.SugaredLogger, db *sqlx.DB, notifier Notifier) Core {
+	return Core{
+		log:      log,
+		db:       db,
+		user:     user.NewStore(log, db),
+		token:    token.NewStore(log, db),
+		audit:    audit.NewStore(log, db),
+		notifier: notifier,
+	}
+}
+
+// Request issues a reset token for the user with the specified email and hands
+// it to the notifier. Unknown emails are not reported so the endpoint can not
+// be used to discover accounts.
+func (c Core) Request(ctx context.Context, email string, now time.Time) error {
+
+	// The caller is not logged in, so the lookup is not bound to a tenant.
+	usr, err := c.user.LookupByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			c.log.Infow("password reset", "status", "unknown email", "email", email)
+			return nil
+		}
+		return fmt.Errorf("request reset failed: %w", err)
+	}
+
+	raw, err := c.token.CreateReset(ctx, usr.ID, now, resetTTL)
+	if err != nil {
+		return fmt.Errorf("request reset failed: %w", err)
+	}
+
+	if err := c.notifier.NotifyReset(ctx, usr, raw, now.Add(resetTTL)); err != nil {
+		return fmt.Errorf("request reset failed: %w", err)
+	}
+
+	return nil
+}
+
+// Complete sets the new password for the user the token was issued to and
+// ends all of their existing login sessions.
+func (c Core) Complete(ctx context.Context, cr CompleteReset, now time.Time) error {
+
+	if err := validate.Check(cr); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	f := func(ctx context.Context) error {
+		userID, err := c.token.ConsumeReset(ctx, cr.Token, now)
+		if err != nil {
+			return err
+		}
+
+		if err := c.user.UpdatePassword(ctx, userID, cr.Password, now); err != nil {
+			return err
+		}
+
+		if err := c.token.RevokeUser(ctx, userID, now); err != nil {
+			return err
+		}
+
+		usr, err := c.user.LookupByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		return c.audit.Record(ctx, auth.Claims{TenantID: usr.TenantID}, audit.EntityUser, userID, audit.ActionPasswordReset, nil, nil, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return fmt.Errorf("complete reset failed: %w", err)
+	}
+
+	return nil
+}
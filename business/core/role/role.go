@@ -0,0 +1,135 @@
+// Package role provides the business logic for managing roles and the
+// permissions they grant.
+package role
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/audit"
+	"github.com/Avyukth/service3-clone/business/data/store/role"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Core struct {
+	log   *zap.SugaredLogger
+	db    *sqlx.DB
+	role  role.Store
+	audit audit.Store
+}
+
+func NewCore(log *zap.SugaredLogger, db *sqlx.DB) Core {
+	return Core{
+		log:   log,
+		db:    db,
+		role:  role.NewStore(log, db),
+		audit: audit.NewStore(log, db),
+	}
+}
+
+func (c Core) Create(ctx context.Context, claims auth.Claims, nr role.NewRole, now time.Time) (role.Role, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	var rl role.Role
+	f := func(ctx context.Context) error {
+		var err error
+		rl, err = c.role.Create(ctx, nr, now)
+		if err != nil {
+			return err
+		}
+		return c.audit.Record(ctx, claims, audit.EntityRole, rl.Name, audit.ActionCreate, nil, rl, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return role.Role{}, fmt.Errorf("create role failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return rl, nil
+}
+
+func (c Core) Update(ctx context.Context, claims auth.Claims, name string, ur role.UpdateRole, now time.Time) error {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	f := func(ctx context.Context) error {
+		before, err := c.role.QueryByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		if err := c.role.Update(ctx, name, ur, now); err != nil {
+			return err
+		}
+		after, err := c.role.QueryByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		return c.audit.Record(ctx, claims, audit.EntityRole, name, audit.ActionUpdate, before, after, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return fmt.Errorf("update role failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return nil
+}
+
+func (c Core) Delete(ctx context.Context, claims auth.Claims, name string, now time.Time) error {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	f := func(ctx context.Context) error {
+		before, err := c.role.QueryByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		if err := c.role.Delete(ctx, name); err != nil {
+			return err
+		}
+		return c.audit.Record(ctx, claims, audit.EntityRole, name, audit.ActionDelete, before, nil, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return fmt.Errorf("delete role failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return nil
+}
+
+func (c Core) Query(ctx context.Context) ([]role.Role, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	rls, err := c.role.Query(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return rls, nil
+}
+
+func (c Core) QueryByName(ctx context.Context, name string) (role.Role, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	rl, err := c.role.QueryByName(ctx, name)
+	if err != nil {
+		return role.Role{}, fmt.Errorf("query role by name failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return rl, nil
+}
+
+// Permissions implements auth.PermissionResolver.
+func (c Core) Permissions(ctx context.Context, roles []auth.Role) ([]auth.Permission, error) {
+	return c.role.Permissions(ctx, roles)
+}
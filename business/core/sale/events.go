@@ -0,0 +1,10 @@
+package sale
+
+import "github.com/Avyukth/service3-clone/business/data/store/sale"
+
+// SaleCreated is raised when a sale is recorded.
+type SaleCreated struct {
+	Sale sale.Sale `json:"sale"`
+}
+
+func (SaleCreated) EventName() string { return "sale.created" }
@@ -0,0 +1,97 @@
+package sale
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/audit"
+	"github.com/Avyukth/service3-clone/business/data/store/outbox"
+	"github.com/Avyukth/service3-clone/business/data/store/sale"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Core struct {
+	log    *zap.SugaredLogger
+	db     *sqlx.DB
+	sale   sale.Store
+	audit  audit.Store
+	outbox outbox.Store
+}
+
+func NewCore(log *zap.SugaredLogger, db *sqlx.DB) Core {
+	return Core{
+		log:    log,
+		db:     db,
+		sale:   sale.NewStore(log, db),
+		audit:  audit.NewStore(log, db),
+		outbox: outbox.NewStore(log, db),
+	}
+}
+
+func (c Core) Create(ctx context.Context, claims auth.Claims, ns sale.NewSale, now time.Time) (sale.Sale, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	var sl sale.Sale
+	f := func(ctx context.Context) error {
+		var err error
+		sl, err = c.sale.Create(ctx, claims, ns, now)
+		if err != nil {
+			return err
+		}
+		if err := c.audit.Record(ctx, claims, audit.EntitySale, sl.ID, audit.ActionCreate, nil, sl, now); err != nil {
+			return err
+		}
+		return c.outbox.Add(ctx, SaleCreated{Sale: sl}, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return sale.Sale{}, fmt.Errorf("create sale failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return sl, nil
+}
+
+func (c Core) Query(ctx context.Context, claims auth.Claims, pageNumber int, rowsPerPage int) ([]sale.Sale, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	sales, err := c.sale.Query(ctx, claims, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return sales, nil
+}
+
+func (c Core) QueryByID(ctx context.Context, claims auth.Claims, saleID string) (sale.Sale, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	sl, err := c.sale.QueryByID(ctx, claims, saleID)
+	if err != nil {
+		return sale.Sale{}, fmt.Errorf("query sale by id failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return sl, nil
+}
+
+func (c Core) QueryByUserID(ctx context.Context, claims auth.Claims, userID string) ([]sale.Sale, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	sales, err := c.sale.QueryByUserID(ctx, claims, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query sales by user id failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return sales, nil
+}
@@ -0,0 +1,164 @@
+// Package signup provides the business logic for users registering themselves
+// and confirming their email address.
+package signup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	userCore "github.com/Avyukth/service3-clone/business/core/user"
+	"github.com/Avyukth/service3-clone/business/data/store/audit"
+	"github.com/Avyukth/service3-clone/business/data/store/outbox"
+	"github.com/Avyukth/service3-clone/business/data/store/tenant"
+	"github.com/Avyukth/service3-clone/business/data/store/user"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/mail"
+	"github.com/Avyukth/service3-clone/business/sys/sigtoken"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// verifyTTL is how long a verification token can be used.
+const verifyTTL = 24 * time.Hour
+
+// purposeVerify binds verification tokens to this flow so tokens signed for
+// other purposes are rejected.
+const purposeVerify = "verify-email"
+
+// ErrUnknownTenant is returned when the tenant to sign up to does not exist or
+// is not active.
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// NewSignup is what a user provides to register themselves. Roles can not be
+// chosen, every self registered user gets the USER role. Without a tenant the
+// user joins the default tenant.
+type NewSignup struct {
+	TenantID        string `json:"tenant_id" validate:"omitempty,uuid"`
+	Name            string `json:"name" validate:"required"`
+	Email           string `json:"email" validate:"required,email"`
+	Password        string `json:"password" validate:"required"`
+	PasswordConfirm string `json:"password_confirm" validate:"eqfield=Password"`
+}
+
+type Core struct {
+	log    *zap.SugaredLogger
+	db     *sqlx.DB
+	user   user.Store
+	tenant tenant.Store
+	audit  audit.Store
+	outbox outbox.Store
+	mailer mail.Mailer
+	signer *sigtoken.Signer
+}
+
+func NewCore(log *zap.SugaredLogger, db *sqlx.DB, mailer mail.Mailer, signer *sigtoken.Signer) Core {
+	return Core{
+		log:    log,
+		db:     db,
+		user:   user.NewStore(log, db),
+		tenant: tenant.NewStore(log, db),
+		audit:  audit.NewStore(log, db),
+		outbox: outbox.NewStore(log, db),
+		mailer: mailer,
+		signer: signer,
+	}
+}
+
+// Signup creates an unverified user and mails them a verification token. The
+// user is only kept if the mail could be handed off.
+func (c Core) Signup(ctx context.Context, ns NewSignup, now time.Time) (user.User, error) {
+
+	tenantID := ns.TenantID
+	if tenantID == "" {
+		tenantID = tenant.DefaultID
+	}
+
+	tnt, err := c.tenant.QueryByID(ctx, tenantID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) || errors.Is(err, database.ErrInvalidID) {
+			return user.User{}, ErrUnknownTenant
+		}
+		return user.User{}, fmt.Errorf("signup failed: %w", err)
+	}
+	if !tnt.Active {
+		return user.User{}, ErrUnknownTenant
+	}
+
+	nu := user.NewUser{
+		Name:            ns.Name,
+		Email:           ns.Email,
+		Roles:           []auth.Role{auth.RoleUser},
+		Password:        ns.Password,
+		PasswordConfirm: ns.PasswordConfirm,
+	}
+
+	var usr user.User
+	f := func(ctx context.Context) error {
+		var err error
+		usr, err = c.user.CreateUnverified(ctx, tnt.ID, nu, now)
+		if err != nil {
+			return err
+		}
+
+		if err := c.audit.Record(ctx, auth.Claims{TenantID: usr.TenantID}, audit.EntityUser, usr.ID, audit.ActionCreate, nil, usr, now); err != nil {
+			return err
+		}
+
+		if err := c.outbox.Add(ctx, userCore.UserCreated{User: usr}, now); err != nil {
+			return err
+		}
+
+		token, err := c.signer.Sign(purposeVerify, usr.ID, now.Add(verifyTTL))
+		if err != nil {
+			return fmt.Errorf("signing verification token: %w", err)
+		}
+
+		msg := mail.Message{
+			To:      usr.Email,
+			Subject: "Confirm your email address",
+			Body:    fmt.Sprintf("Hi %s,\n\nconfirm your email address with this token, it is valid for %s:\n\n%s\n", usr.Name, verifyTTL, token),
+		}
+		if err := c.mailer.Send(ctx, msg); err != nil {
+			return fmt.Errorf("sending verification mail: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return user.User{}, fmt.Errorf("signup failed: %w", err)
+	}
+
+	return usr, nil
+}
+
+// Confirm verifies the email address of the user the token was issued to.
+func (c Core) Confirm(ctx context.Context, token string, now time.Time) error {
+
+	userID, err := c.signer.Verify(purposeVerify, token, now)
+	if err != nil {
+		return fmt.Errorf("confirm failed: %w", err)
+	}
+
+	f := func(ctx context.Context) error {
+		if err := c.user.Verify(ctx, userID, now); err != nil {
+			return err
+		}
+
+		usr, err := c.user.LookupByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		return c.audit.Record(ctx, auth.Claims{TenantID: usr.TenantID}, audit.EntityUser, userID, audit.ActionVerify, nil, nil, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return fmt.Errorf("confirm failed: %w", err)
+	}
+
+	return nil
+}
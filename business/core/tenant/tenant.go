@@ -0,0 +1,133 @@
+// Package tenant provides the business logic for managing the tenants the
+// users, products and sales of the service belong to.
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/audit"
+	"github.com/Avyukth/service3-clone/business/data/store/tenant"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Core struct {
+	log    *zap.SugaredLogger
+	db     *sqlx.DB
+	tenant tenant.Store
+	audit  audit.Store
+}
+
+func NewCore(log *zap.SugaredLogger, db *sqlx.DB) Core {
+	return Core{
+		log:    log,
+		db:     db,
+		tenant: tenant.NewStore(log, db),
+		audit:  audit.NewStore(log, db),
+	}
+}
+
+func (c Core) Create(ctx context.Context, claims auth.Claims, nt tenant.NewTenant, now time.Time) (tenant.Tenant, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	var tnt tenant.Tenant
+	f := func(ctx context.Context) error {
+		var err error
+		tnt, err = c.tenant.Create(ctx, nt, now)
+		if err != nil {
+			return err
+		}
+		return c.audit.Record(ctx, claims, audit.EntityTenant, tnt.ID, audit.ActionCreate, nil, tnt, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return tenant.Tenant{}, fmt.Errorf("create tenant failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return tnt, nil
+}
+
+// Update changes the name of the tenant or suspends it. Users of a suspended
+// tenant can no longer log in or refresh their tokens.
+func (c Core) Update(ctx context.Context, claims auth.Claims, tenantID string, ut tenant.UpdateTenant, now time.Time) error {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	f := func(ctx context.Context) error {
+		before, err := c.tenant.QueryByID(ctx, tenantID)
+		if err != nil {
+			return err
+		}
+		if err := c.tenant.Update(ctx, tenantID, ut, now); err != nil {
+			return err
+		}
+		after, err := c.tenant.QueryByID(ctx, tenantID)
+		if err != nil {
+			return err
+		}
+		return c.audit.Record(ctx, claims, audit.EntityTenant, tenantID, audit.ActionUpdate, before, after, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return fmt.Errorf("update tenant failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return nil
+}
+
+// Delete removes a tenant that no longer owns any users, products or sales.
+func (c Core) Delete(ctx context.Context, claims auth.Claims, tenantID string, now time.Time) error {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	f := func(ctx context.Context) error {
+		before, err := c.tenant.QueryByID(ctx, tenantID)
+		if err != nil {
+			return err
+		}
+		if err := c.tenant.Delete(ctx, tenantID); err != nil {
+			return err
+		}
+		return c.audit.Record(ctx, claims, audit.EntityTenant, tenantID, audit.ActionDelete, before, nil, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return fmt.Errorf("delete tenant failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return nil
+}
+
+func (c Core) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]tenant.Tenant, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	tnts, err := c.tenant.Query(ctx, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return tnts, nil
+}
+
+func (c Core) QueryByID(ctx context.Context, tenantID string) (tenant.Tenant, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	tnt, err := c.tenant.QueryByID(ctx, tenantID)
+	if err != nil {
+		return tenant.Tenant{}, fmt.Errorf("query tenant by id failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return tnt, nil
+}
@@ -0,0 +1,25 @@
+package user
+
+import "github.com/Avyukth/service3-clone/business/data/store/user"
+
+// UserCreated is raised when a user is added, by an admin or by signing up.
+type UserCreated struct {
+	User user.User `json:"user"`
+}
+
+func (UserCreated) EventName() string { return "user.created" }
+
+// UserUpdated is raised when the details of a user change, including when a
+// deleted user is restored.
+type UserUpdated struct {
+	User user.User `json:"user"`
+}
+
+func (UserUpdated) EventName() string { return "user.updated" }
+
+// UserDeleted is raised when a user is deleted.
+type UserDeleted struct {
+	UserID string `json:"user_id"`
+}
+
+func (UserDeleted) EventName() string { return "user.deleted" }
@@ -2,33 +2,81 @@ package user
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/Avyukth/service3-clone/business/data/order"
+	"github.com/Avyukth/service3-clone/business/data/store/audit"
+	"github.com/Avyukth/service3-clone/business/data/store/outbox"
+	"github.com/Avyukth/service3-clone/business/data/store/role"
+	"github.com/Avyukth/service3-clone/business/data/store/token"
 	"github.com/Avyukth/service3-clone/business/data/store/user"
 	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/policy"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
+// refreshTTL is how long a refresh token can be exchanged for a new token pair.
+const refreshTTL = 7 * 24 * time.Hour
+
+// deleteRetention is how long a deleted user can be restored before a purge
+// removes it for good.
+const deleteRetention = 30 * 24 * time.Hour
+
 type Core struct {
-	log  *zap.SugaredLogger
-	user user.Store
+	log    *zap.SugaredLogger
+	db     *sqlx.DB
+	user   user.Store
+	token  token.Store
+	role   role.Store
+	audit  audit.Store
+	outbox outbox.Store
 }
 
 func NewCore(log *zap.SugaredLogger, db *sqlx.DB) Core {
 	return Core{
-		log:  log,
-		user: user.NewStore(log, db),
+		log:    log,
+		db:     db,
+		user:   user.NewStore(log, db),
+		token:  token.NewStore(log, db),
+		role:   role.NewStore(log, db),
+		audit:  audit.NewStore(log, db),
+		outbox: outbox.NewStore(log, db),
 	}
 }
 
-func (c Core) Create(ctx context.Context, nu user.NewUser, now time.Time) (user.User, error) {
+// Create adds a user to the tenant of the claims.
+func (c Core) Create(ctx context.Context, claims auth.Claims, nu user.NewUser, now time.Time) (user.User, error) {
 
 	// PERFORM PRE BUSINESSES OPERATIONS
 
-	usr, err := c.user.Create(ctx, nu, now)
-	if err != nil {
+	for _, role := range nu.Roles {
+		if role == auth.RoleSuperAdmin && !policy.Allowed(claims, policy.UserGrantSuperAdmin, policy.Resource{TenantID: claims.TenantID}) {
+			return user.User{}, database.ErrForbidden
+		}
+	}
+
+	var usr user.User
+	f := func(ctx context.Context) error {
+		if err := c.role.Check(ctx, nu.Roles); err != nil {
+			return err
+		}
+
+		var err error
+		usr, err = c.user.Create(ctx, claims.TenantID, nu, now)
+		if err != nil {
+			return err
+		}
+		if err := c.audit.Record(ctx, claims, audit.EntityUser, usr.ID, audit.ActionCreate, nil, usr, now); err != nil {
+			return err
+		}
+		return c.outbox.Add(ctx, UserCreated{User: usr}, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
 		return user.User{}, fmt.Errorf("create user failed: %w", err)
 	}
 
@@ -37,44 +85,208 @@ func (c Core) Create(ctx context.Context, nu user.NewUser, now time.Time) (user.
 	return usr, nil
 }
 
-func (c Core) Update(ctx context.Context, claims auth.Claims, userID string, uu user.UpdateUser, now time.Time) error {
+func (c Core) Update(ctx context.Context, claims auth.Claims, userID string, uu user.UpdateUser, version int, now time.Time) error {
 	// PERFORM PRE BUSINESSES OPERATIONS
 
-	if err := c.user.Update(ctx, claims, userID, uu, now); err != nil {
+	f := func(ctx context.Context) error {
+		return c.update(ctx, claims, userID, uu, version, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
 		return fmt.Errorf("update user failed: %w", err)
 	}
 
 	// PERFORM POST BUSINESSES OPERATIONS
 
 	return nil
+}
+
+func (c Core) UpdateProfile(ctx context.Context, claims auth.Claims, up user.UpdateProfile, version int, now time.Time) error {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	uu := user.UpdateUser{
+		Name:  up.Name,
+		Email: up.Email,
+	}
+
+	f := func(ctx context.Context) error {
+		return c.update(ctx, claims, claims.Subject, uu, version, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return fmt.Errorf("update profile failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return nil
+}
+
+// update applies the change, records the user as it was before and after and
+// raises UserUpdated. It must be called within a transaction.
+func (c Core) update(ctx context.Context, claims auth.Claims, userID string, uu user.UpdateUser, version int, now time.Time) error {
+	if uu.Roles != nil {
+		if err := c.role.Check(ctx, uu.Roles); err != nil {
+			return err
+		}
+	}
+
+	before, err := c.user.QueryByID(ctx, claims, userID)
+	if err != nil {
+		return err
+	}
 
+	if err := c.user.Update(ctx, claims, userID, uu, version, now); err != nil {
+		return err
+	}
+
+	after, err := c.user.QueryByID(ctx, claims, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.audit.Record(ctx, claims, audit.EntityUser, userID, audit.ActionUpdate, before, after, now); err != nil {
+		return err
+	}
+
+	return c.outbox.Add(ctx, UserUpdated{User: after}, now)
+}
+
+// ChangePassword changes the password of the calling user and revokes all of
+// their refresh tokens, so other sessions have to log in again.
+func (c Core) ChangePassword(ctx context.Context, claims auth.Claims, cp user.ChangePassword, now time.Time) error {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	f := func(ctx context.Context) error {
+		if err := c.user.ChangePassword(ctx, claims, claims.Subject, cp, now); err != nil {
+			return err
+		}
+		if err := c.token.RevokeUser(ctx, claims.Subject, now); err != nil {
+			return err
+		}
+		return c.audit.Record(ctx, claims, audit.EntityUser, claims.Subject, audit.ActionPasswordChange, nil, nil, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return fmt.Errorf("change password failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return nil
 }
 
-func (c Core) Delete(ctx context.Context, claims auth.Claims, userID string) error {
+// Delete soft deletes the user and ends their login sessions.
+func (c Core) Delete(ctx context.Context, claims auth.Claims, userID string, version int, now time.Time) error {
 	// PERFORM PRE BUSINESSES OPERATIONS
 
-	if err := c.user.Delete(ctx, claims, userID); err != nil {
+	f := func(ctx context.Context) error {
+		before, err := c.user.QueryByID(ctx, claims, userID)
+		if err != nil {
+			return err
+		}
+		if err := c.user.Delete(ctx, claims, userID, version, now); err != nil {
+			return err
+		}
+		if err := c.token.RevokeUser(ctx, userID, now); err != nil {
+			return err
+		}
+		if err := c.audit.Record(ctx, claims, audit.EntityUser, userID, audit.ActionDelete, before, nil, now); err != nil {
+			return err
+		}
+		return c.outbox.Add(ctx, UserDeleted{UserID: userID}, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
 		return fmt.Errorf("delete user failed: %w", err)
 	}
 
 	// PERFORM POST BUSINESSES OPERATIONS
 
 	return nil
+}
+
+// Restore brings back a soft deleted user.
+func (c Core) Restore(ctx context.Context, claims auth.Claims, userID string, now time.Time) error {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	f := func(ctx context.Context) error {
+		if err := c.user.Restore(ctx, claims, userID, now); err != nil {
+			return err
+		}
+		after, err := c.user.QueryByID(ctx, claims, userID)
+		if err != nil {
+			return err
+		}
+		if err := c.audit.Record(ctx, claims, audit.EntityUser, userID, audit.ActionRestore, nil, after, now); err != nil {
+			return err
+		}
+		return c.outbox.Add(ctx, UserUpdated{User: after}, now)
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return fmt.Errorf("restore user failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return nil
+}
+
+// Purge permanently removes users that have been deleted for longer than the
+// retention window and returns how many were removed.
+func (c Core) Purge(ctx context.Context, claims auth.Claims, now time.Time) (int, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	var purged []string
+	f := func(ctx context.Context) error {
+		var err error
+		purged, err = c.user.Purge(ctx, claims, now.Add(-deleteRetention))
+		if err != nil {
+			return err
+		}
+		for _, userID := range purged {
+			if err := c.audit.Record(ctx, claims, audit.EntityUser, userID, audit.ActionPurge, nil, nil, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return 0, fmt.Errorf("purge users failed: %w", err)
+	}
 
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return len(purged), nil
 }
 
-func (c Core) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]user.User, error) {
+func (c Core) Query(ctx context.Context, claims auth.Claims, filter user.QueryFilter, orderBy order.By, after string, rowsPerPage int) ([]user.User, string, error) {
 	// PERFORM PRE BUSINESSES OPERATIONS
 
-	users, err := c.user.Query(ctx, pageNumber, rowsPerPage)
+	users, next, err := c.user.Query(ctx, claims, filter, orderBy, after, rowsPerPage)
 	if err != nil {
-		return nil, fmt.Errorf("query failed: %w", err)
+		return nil, "", fmt.Errorf("query failed: %w", err)
 	}
 
 	// PERFORM POST BUSINESSES OPERATIONS
 
-	return users, nil
+	return users, next, nil
+
+}
+
+func (c Core) Count(ctx context.Context, claims auth.Claims, filter user.QueryFilter) (int, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	total, err := c.user.Count(ctx, claims, filter)
+	if err != nil {
+		return 0, fmt.Errorf("count failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
 
+	return total, nil
 }
 
 func (c Core) QueryById(ctx context.Context, claims auth.Claims, userId string) (user.User, error) {
@@ -116,3 +328,96 @@ func (c Core) Authenticate(ctx context.Context, now time.Time, email string, pas
 
 	return claims, nil
 }
+
+func (c Core) NewRefreshToken(ctx context.Context, claims auth.Claims, now time.Time) (string, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	refresh, err := c.token.Create(ctx, claims.Subject, now, refreshTTL)
+	if err != nil {
+		return "", fmt.Errorf("issuing refresh token failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return refresh, nil
+}
+
+// Refresh rotates the refresh token and returns fresh claims for its owner
+// together with the replacement refresh token. The token is only consumed
+// when the claims could be built.
+func (c Core) Refresh(ctx context.Context, refreshToken string, now time.Time) (auth.Claims, string, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	var claims auth.Claims
+	var next string
+	var reused error
+
+	f := func(ctx context.Context) error {
+		userID, rotated, err := c.token.Rotate(ctx, refreshToken, now, refreshTTL)
+		if err != nil {
+			if errors.Is(err, token.ErrReused) {
+				reused = err
+				return nil
+			}
+			return fmt.Errorf("rotating refresh token failed: %w", err)
+		}
+
+		claims, err = c.user.Claims(ctx, now, userID)
+		if err != nil {
+			return fmt.Errorf("refreshing claims failed: %w", err)
+		}
+		next = rotated
+
+		return nil
+	}
+
+	// Reusing a token revokes its family, which must be committed, so the
+	// reuse error is only reported once the transaction has finished.
+	if err := database.WithinTran(ctx, c.db, f); err != nil {
+		return auth.Claims{}, "", err
+	}
+	if reused != nil {
+		return auth.Claims{}, "", fmt.Errorf("rotating refresh token failed: %w", reused)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return claims, next, nil
+}
+
+// Revoke denies the access token described by the claims for the rest of its
+// lifetime and, when provided, revokes the refresh token family as well.
+func (c Core) Revoke(ctx context.Context, claims auth.Claims, refreshToken string, now time.Time) error {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	if refreshToken != "" {
+		if err := c.token.Revoke(ctx, claims, refreshToken, now); err != nil {
+			return fmt.Errorf("revoking refresh token failed: %w", err)
+		}
+	}
+
+	// Tokens issued without an id cannot be put on the deny list.
+	if claims.ID != "" {
+		expires := now
+		if claims.ExpiresAt != nil {
+			expires = claims.ExpiresAt.Time
+		}
+		if err := c.token.RevokeAccess(ctx, claims.ID, expires); err != nil {
+			return fmt.Errorf("revoking access token failed: %w", err)
+		}
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	// Entries of tokens that expired on their own deny nothing anymore.
+	if err := c.token.PruneRevoked(ctx, now); err != nil {
+		return fmt.Errorf("pruning revoked tokens failed: %w", err)
+	}
+
+	return nil
+}
+
+// IsRevoked implements auth.Revoker.
+func (c Core) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return c.token.IsRevoked(ctx, jti)
+}
@@ -0,0 +1,321 @@
+// Package webhook provides the business logic for sending events to external
+// systems. Events are queued per subscribed webhook when they are relayed from
+// the outbox and a worker delivers them, retrying with exponential backoff
+// until a delivery is given up on as dead.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/webhook"
+	"github.com/Avyukth/service3-clone/business/sys/events"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Headers set on every delivery. The signature header holds the time the
+// delivery was signed and the hex encoded HMAC-SHA256 of "<time>.<body>":
+//
+//	X-Webhook-Signature: t=1700000000,v1=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	batchSize   = 20
+	maxAttempts = 10
+	backoffBase = 30 * time.Second
+	backoffMax  = 6 * time.Hour
+
+	// leaseTime is how long claimed deliveries are kept from other workers. It
+	// covers sending a whole batch when every request times out.
+	leaseTime = 5 * time.Minute
+)
+
+type Core struct {
+	log     *zap.SugaredLogger
+	db      *sqlx.DB
+	webhook webhook.Store
+	client  *http.Client
+}
+
+func NewCore(log *zap.SugaredLogger, db *sqlx.DB) Core {
+	return Core{
+		log:     log,
+		db:      db,
+		webhook: webhook.NewStore(log, db),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Created is a new webhook together with its signing secret.
+type Created struct {
+	webhook.Webhook
+	Secret string `json:"secret"`
+}
+
+// Create registers a webhook with a freshly generated signing secret.
+func (c Core) Create(ctx context.Context, nw webhook.NewWebhook, now time.Time) (Created, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	secret, err := newSecret()
+	if err != nil {
+		return Created{}, fmt.Errorf("create webhook failed: %w", err)
+	}
+
+	wh, err := c.webhook.Create(ctx, nw, secret, now)
+	if err != nil {
+		return Created{}, fmt.Errorf("create webhook failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return Created{Webhook: wh, Secret: secret}, nil
+}
+
+func (c Core) Update(ctx context.Context, webhookID string, uw webhook.UpdateWebhook, now time.Time) error {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	if err := c.webhook.Update(ctx, webhookID, uw, now); err != nil {
+		return fmt.Errorf("update webhook failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return nil
+}
+
+func (c Core) Delete(ctx context.Context, webhookID string) error {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	if err := c.webhook.Delete(ctx, webhookID); err != nil {
+		return fmt.Errorf("delete webhook failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return nil
+}
+
+func (c Core) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]webhook.Webhook, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	whs, err := c.webhook.Query(ctx, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return whs, nil
+}
+
+func (c Core) QueryByID(ctx context.Context, webhookID string) (webhook.Webhook, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	wh, err := c.webhook.QueryByID(ctx, webhookID)
+	if err != nil {
+		return webhook.Webhook{}, fmt.Errorf("query webhook by id failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return wh, nil
+}
+
+// QueryDeliveries returns a page of the delivery history of a webhook.
+func (c Core) QueryDeliveries(ctx context.Context, webhookID string, status string, pageNumber int, rowsPerPage int) ([]webhook.Delivery, error) {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	if _, err := c.webhook.QueryByID(ctx, webhookID); err != nil {
+		return nil, fmt.Errorf("query deliveries failed: %w", err)
+	}
+
+	dls, err := c.webhook.QueryDeliveries(ctx, webhookID, status, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query deliveries failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return dls, nil
+}
+
+// Redeliver sends a delivery again, typically one that is dead.
+func (c Core) Redeliver(ctx context.Context, webhookID string, deliveryID string, now time.Time) error {
+	// PERFORM PRE BUSINESSES OPERATIONS
+
+	if err := c.webhook.Redeliver(ctx, webhookID, deliveryID, now); err != nil {
+		return fmt.Errorf("redeliver failed: %w", err)
+	}
+
+	// PERFORM POST BUSINESSES OPERATIONS
+
+	return nil
+}
+
+// Enqueue queues the event for every active webhook subscribed to it. It is
+// meant to be subscribed to the event bus so it runs in the transaction that
+// marks the event as relayed.
+func (c Core) Enqueue(ctx context.Context, env events.Envelope) error {
+
+	whs, err := c.webhook.QueryByEvent(ctx, env.Name)
+	if err != nil {
+		return fmt.Errorf("enqueue event failed: %w", err)
+	}
+	if len(whs) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("enqueue event failed: %w", err)
+	}
+
+	now := time.Now()
+
+	for _, wh := range whs {
+		d := webhook.Delivery{
+			ID:          validate.GenerateID(),
+			WebhookID:   wh.ID,
+			EventID:     env.ID,
+			EventName:   env.Name,
+			Payload:     body,
+			Status:      webhook.StatusPending,
+			NextAttempt: now,
+			DateCreated: now,
+			DateUpdated: now,
+		}
+		if err := c.webhook.CreateDelivery(ctx, d); err != nil {
+			return fmt.Errorf("enqueue event failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Deliver sends a batch of due deliveries and returns how many succeeded.
+// A failed delivery is tried again after a growing delay and marked dead once
+// it ran out of attempts. The batch is claimed up front so no transaction or
+// row lock is held while the requests are sent, and each outcome is stored on
+// its own.
+func (c Core) Deliver(ctx context.Context, now time.Time) (int, error) {
+
+	due, err := c.webhook.ClaimDue(ctx, now, now.Add(leaseTime), batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("deliver failed: %w", err)
+	}
+
+	var delivered int
+	for _, p := range due {
+		d := p.Delivery
+		d.Attempts++
+		d.DateUpdated = now
+
+		code, err := c.send(ctx, p, now)
+		if code != 0 {
+			d.LastStatusCode = &code
+		}
+
+		switch {
+		case err == nil:
+			d.Status = webhook.StatusDelivered
+			d.LastError = nil
+			delivered++
+
+		case d.Attempts >= maxAttempts:
+			msg := err.Error()
+			d.Status = webhook.StatusDead
+			d.LastError = &msg
+			c.log.Errorw("webhook", "status", "delivery dead", "delivery_id", d.ID, "webhook_id", d.WebhookID, "ERROR", err)
+
+		default:
+			msg := err.Error()
+			d.LastError = &msg
+			d.NextAttempt = now.Add(Backoff(d.Attempts))
+		}
+
+		// A delivery whose outcome can not be stored is sent again once its
+		// lease runs out, the others keep theirs.
+		if err := c.webhook.UpdateDelivery(ctx, d); err != nil {
+			c.log.Errorw("webhook", "status", "recording delivery", "delivery_id", d.ID, "webhook_id", d.WebhookID, "ERROR", err)
+		}
+	}
+
+	return delivered, nil
+}
+
+// send posts the delivery to the webhook and returns the response status.
+func (c Core) send(ctx context.Context, p webhook.Pending, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(p.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("building request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, p.EventName)
+	req.Header.Set(HeaderDelivery, p.ID)
+	req.Header.Set(HeaderSignature, Sign(p.Secret, now, p.Payload))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign returns the value of the signature header for the body signed at the
+// specified time. Receivers compute the same value with their copy of the
+// secret and compare.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns how long to wait before the next attempt after the
+// specified number of failed attempts.
+func Backoff(attempts int) time.Duration {
+	d := backoffBase
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= backoffMax {
+			return backoffMax
+		}
+	}
+	return d
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,69 @@
+package webhook_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/core/webhook"
+)
+
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestSign(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{"name":"user.created"}`)
+	now := time.Unix(1700000000, 0)
+
+	t.Log("Given the need to sign webhook deliveries.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen signing a body.", testID)
+		{
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write([]byte("1700000000." + string(body)))
+			exp := "t=1700000000,v1=" + hex.EncodeToString(mac.Sum(nil))
+
+			if got := webhook.Sign(secret, now, body); got != exp {
+				t.Fatalf("\t%s\tTest %d:\tShould sign the time and body: got %s, exp %s", failed, testID, got, exp)
+			}
+			t.Logf("\t%s\tTest %d:\tShould sign the time and body.", success, testID)
+
+			if webhook.Sign("other", now, body) == exp {
+				t.Fatalf("\t%s\tTest %d:\tShould depend on the secret.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould depend on the secret.", success, testID)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tt := []struct {
+		attempts int
+		exp      time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{9, 128 * time.Minute},
+		{20, 6 * time.Hour},
+	}
+
+	t.Log("Given the need to retry failed deliveries with a growing delay.")
+	{
+		for testID, tst := range tt {
+			t.Logf("\tTest %d:\tWhen %d attempts failed.", testID, tst.attempts)
+			{
+				if got := webhook.Backoff(tst.attempts); got != tst.exp {
+					t.Fatalf("\t%s\tTest %d:\tShould wait %s, got %s.", failed, testID, tst.exp, got)
+				}
+				t.Logf("\t%s\tTest %d:\tShould wait %s.", success, testID, tst.exp)
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Package order provides support for describing the ordering of data.
+package order
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Set of directions for data ordering.
+const (
+	ASC  = "ASC"
+	DESC = "DESC"
+)
+
+var directions = map[string]string{
+	ASC:  "ASC",
+	DESC: "DESC",
+}
+
+// By represents a field used to order by and direction.
+type By struct {
+	Field     string
+	Direction string
+}
+
+// NewBy constructs a new By value with no checks.
+func NewBy(field string, direction string) By {
+	return By{
+		Field:     field,
+		Direction: direction,
+	}
+}
+
+// Parse constructs a By value from a "field,direction" string. The field must
+// be a key in the fieldMappings allow-list and the direction defaults to ASC
+// when omitted. An empty value returns defaultOrder.
+func Parse(orderBy string, fieldMappings map[string]string, defaultOrder By) (By, error) {
+	if orderBy == "" {
+		return defaultOrder, nil
+	}
+
+	parts := strings.Split(orderBy, ",")
+
+	field := strings.TrimSpace(parts[0])
+	if _, exists := fieldMappings[field]; !exists {
+		return By{}, fmt.Errorf("unknown order field %q", field)
+	}
+
+	switch len(parts) {
+	case 1:
+		return NewBy(field, ASC), nil
+
+	case 2:
+		dir := strings.ToUpper(strings.TrimSpace(parts[1]))
+		if _, exists := directions[dir]; !exists {
+			return By{}, fmt.Errorf("unknown order direction %q", dir)
+		}
+		return NewBy(field, dir), nil
+
+	default:
+		return By{}, fmt.Errorf("unknown order field %q", orderBy)
+	}
+}
@@ -0,0 +1,61 @@
+package order_test
+
+import (
+	"testing"
+
+	"github.com/Avyukth/service3-clone/business/data/order"
+)
+
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestParse(t *testing.T) {
+	fields := map[string]string{
+		"user_id": "user_id",
+		"name":    "name",
+	}
+	defaultOrder := order.NewBy("user_id", order.ASC)
+
+	tt := []struct {
+		name    string
+		orderBy string
+		exp     order.By
+		wantErr bool
+	}{
+		{name: "empty", orderBy: "", exp: defaultOrder},
+		{name: "field", orderBy: "name", exp: order.NewBy("name", order.ASC)},
+		{name: "field-direction", orderBy: "name,desc", exp: order.NewBy("name", order.DESC)},
+		{name: "unknown-field", orderBy: "password_hash", wantErr: true},
+		{name: "unknown-direction", orderBy: "name,sideways", wantErr: true},
+		{name: "too-many-parts", orderBy: "name,ASC,DESC", wantErr: true},
+	}
+
+	t.Log("Given the need to parse order by values.")
+	{
+		for testID, tst := range tt {
+			t.Logf("\tTest %d:\tWhen parsing %q.", testID, tst.orderBy)
+			{
+				got, err := order.Parse(tst.orderBy, fields, defaultOrder)
+				if tst.wantErr {
+					if err == nil {
+						t.Fatalf("\t%s\tTest %d:\tShould reject the value.", failed, testID)
+					}
+					t.Logf("\t%s\tTest %d:\tShould reject the value.", success, testID)
+					continue
+				}
+
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to parse the value: %v", failed, testID, err)
+				}
+				if got != tst.exp {
+					t.Logf("\t\tTest %d:\texp: %+v", testID, tst.exp)
+					t.Logf("\t\tTest %d:\tgot: %+v", testID, got)
+					t.Fatalf("\t%s\tTest %d:\tShould get the expected order.", failed, testID)
+				}
+				t.Logf("\t%s\tTest %d:\tShould get the expected order.", success, testID)
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ardanlabs/darwin"
+)
+
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestMigrations(t *testing.T) {
+	t.Log("Given the need to migrate the database with the embedded schema.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen parsing the schema.", testID)
+		{
+			migs := darwin.ParseMigrations(schemaDoc)
+			if len(migs) == 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to parse the migrations.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to parse the migrations.", success, testID)
+
+			for i := 1; i < len(migs); i++ {
+				if migs[i].Version <= migs[i-1].Version {
+					t.Fatalf("\t%s\tTest %d:\tShould list the versions in increasing order : %v %q after %v %q.", failed, testID, migs[i].Version, migs[i].Description, migs[i-1].Version, migs[i-1].Description)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould list the versions in increasing order.", success, testID)
+
+			if err := darwin.Validate(emptyDriver{}, migs); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to validate the migrations : %s.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to validate the migrations.", success, testID)
+		}
+	}
+}
+
+// emptyDriver is a darwin driver for a database no migration was applied to.
+type emptyDriver struct{}
+
+func (emptyDriver) Create() error                          { return nil }
+func (emptyDriver) Insert(e darwin.MigrationRecord) error  { return nil }
+func (emptyDriver) All() ([]darwin.MigrationRecord, error) { return nil, nil }
+func (emptyDriver) Exec(string) (time.Duration, error)     { return 0, nil }
@@ -1,3 +1,14 @@
+DELETE FROM webhook_deliveries;
+DELETE FROM webhooks;
+DELETE FROM outbox;
+DELETE FROM audit_log;
+DELETE FROM password_resets;
+DELETE FROM revoked_tokens;
+DELETE FROM oauth_clients;
+DELETE FROM api_keys;
+DELETE FROM refresh_tokens;
 DELETE FROM sales;
 DELETE FROM products;
 DELETE FROM users;
+DELETE FROM roles WHERE name NOT IN ('SUPER_ADMIN', 'ADMIN', 'USER');
+DELETE FROM tenants WHERE tenant_id <> '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d';
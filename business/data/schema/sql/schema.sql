@@ -42,3 +42,287 @@ CREATE TABLE IF NOT EXISTS sales (
 	-- FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
 	-- FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE
 );
+
+-- Version: 1.4
+-- Description: Create table refresh_tokens
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	token_id     UUID,
+	user_id      UUID NOT NULL,
+	family_id    UUID NOT NULL,
+	token_hash   TEXT NOT NULL UNIQUE,
+	replaced_by  UUID,
+	date_expires TIMESTAMP WITH TIME ZONE NOT NULL,
+	date_revoked TIMESTAMP WITH TIME ZONE,
+	date_created TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+	PRIMARY KEY (token_id)
+);
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_idx ON refresh_tokens (family_id);
+
+-- Version: 1.5
+-- Description: Create table revoked_tokens
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	jti          TEXT,
+	date_expires TIMESTAMP WITH TIME ZONE NOT NULL,
+	date_created TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+	PRIMARY KEY (jti)
+);
+
+-- Version: 1.6
+-- Description: Track failed logins on users
+ALTER TABLE users
+	ADD COLUMN IF NOT EXISTS failed_logins INT NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS locked_until  TIMESTAMP WITH TIME ZONE;
+
+-- Version: 1.7
+-- Description: Track email verification on users
+ALTER TABLE users
+	ADD COLUMN IF NOT EXISTS date_verified TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+
+-- Version: 1.8
+-- Description: Create table password_resets
+CREATE TABLE IF NOT EXISTS password_resets (
+	reset_id     UUID,
+	user_id      UUID NOT NULL,
+	token_hash   TEXT NOT NULL UNIQUE,
+	date_expires TIMESTAMP WITH TIME ZONE NOT NULL,
+	date_used    TIMESTAMP WITH TIME ZONE,
+	date_created TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+	PRIMARY KEY (reset_id)
+);
+
+-- Version: 1.9
+-- Description: Soft delete users
+ALTER TABLE users
+	ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS users_deleted_at_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Version: 2.0
+-- Description: Version users for optimistic concurrency
+ALTER TABLE users
+	ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+-- Version: 2.1
+-- Description: Create table audit_log
+CREATE TABLE IF NOT EXISTS audit_log (
+	audit_id     UUID,
+	actor_id     TEXT,
+	trace_id     TEXT,
+	entity       TEXT NOT NULL,
+	entity_id    TEXT NOT NULL,
+	action       TEXT NOT NULL,
+	before       JSONB,
+	after        JSONB,
+	date_created TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+	PRIMARY KEY (audit_id)
+);
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity, entity_id);
+CREATE INDEX IF NOT EXISTS audit_log_date_created_idx ON audit_log (date_created);
+
+-- Version: 2.2
+-- Description: Create table outbox
+CREATE TABLE IF NOT EXISTS outbox (
+	event_id       UUID,
+	name           TEXT NOT NULL,
+	payload        JSONB NOT NULL,
+	trace_id       TEXT,
+	attempts       INT NOT NULL DEFAULT 0,
+	last_error     TEXT,
+	date_created   TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	date_published TIMESTAMP WITH TIME ZONE,
+
+	PRIMARY KEY (event_id)
+);
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (date_created) WHERE date_published IS NULL;
+
+-- Version: 2.3
+-- Description: Create tables webhooks and webhook_deliveries
+CREATE TABLE IF NOT EXISTS webhooks (
+	webhook_id   UUID,
+	url          TEXT NOT NULL,
+	secret       TEXT NOT NULL,
+	events       TEXT[] NOT NULL,
+	active       BOOLEAN NOT NULL DEFAULT TRUE,
+	date_created TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	date_updated TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+	PRIMARY KEY (webhook_id)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	delivery_id      UUID,
+	webhook_id       UUID NOT NULL,
+	event_id         UUID NOT NULL,
+	event_name       TEXT NOT NULL,
+	payload          JSONB NOT NULL,
+	status           TEXT NOT NULL,
+	attempts         INT NOT NULL DEFAULT 0,
+	next_attempt     TIMESTAMP WITH TIME ZONE NOT NULL,
+	last_status_code INT,
+	last_error       TEXT,
+	date_created     TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	date_updated     TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+	PRIMARY KEY (delivery_id),
+	UNIQUE (webhook_id, event_id),
+	FOREIGN KEY (webhook_id) REFERENCES webhooks(webhook_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt) WHERE status = 'pending';
+
+-- Version: 2.4
+-- Description: Create tables roles and role_permissions
+CREATE TABLE IF NOT EXISTS roles (
+	name         TEXT,
+	description  TEXT NOT NULL DEFAULT '',
+	date_created TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	date_updated TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+	PRIMARY KEY (name)
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+	role_name  TEXT,
+	permission TEXT,
+
+	PRIMARY KEY (role_name, permission),
+	FOREIGN KEY (role_name) REFERENCES roles(name) ON DELETE CASCADE
+);
+
+INSERT INTO roles (name, description) VALUES
+	('ADMIN', 'Full access to every resource'),
+	('USER', 'Manage their own products and sales')
+	ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_name, permission) VALUES
+	('ADMIN', 'users:read'),
+	('ADMIN', 'users:write'),
+	('ADMIN', 'products:read'),
+	('ADMIN', 'products:write'),
+	('ADMIN', 'sales:read'),
+	('ADMIN', 'sales:write'),
+	('ADMIN', 'roles:read'),
+	('ADMIN', 'roles:write'),
+	('ADMIN', 'audit:read'),
+	('ADMIN', 'webhooks:read'),
+	('ADMIN', 'webhooks:write'),
+	('USER', 'products:read'),
+	('USER', 'products:write'),
+	('USER', 'sales:read'),
+	('USER', 'sales:write')
+	ON CONFLICT DO NOTHING;
+
+-- Version: 2.5
+-- Description: Store user roles as an array
+ALTER TABLE users
+	ALTER COLUMN roles TYPE TEXT[]
+	USING array_remove(regexp_split_to_array(btrim(regexp_replace(roles, '\s', '', 'g'), '{}'), ','), '');
+
+ALTER TABLE users
+	ALTER COLUMN roles SET DEFAULT '{}';
+
+-- Version: 2.6
+-- Description: Create table tenants and scope users, products, sales and the audit log to a tenant
+CREATE TABLE IF NOT EXISTS tenants (
+	tenant_id    UUID,
+	name         TEXT NOT NULL UNIQUE,
+	active       BOOLEAN NOT NULL DEFAULT TRUE,
+	date_created TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	date_updated TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+	PRIMARY KEY (tenant_id)
+);
+
+INSERT INTO tenants (tenant_id, name) VALUES
+	('0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d', 'Default')
+	ON CONFLICT DO NOTHING;
+
+-- Existing rows belong to the default tenant. The defaults are dropped again
+-- so every new row has to name its tenant.
+ALTER TABLE users
+	ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d' REFERENCES tenants(tenant_id);
+ALTER TABLE users
+	ALTER COLUMN tenant_id DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS users_tenant_idx ON users (tenant_id);
+
+ALTER TABLE products
+	ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d' REFERENCES tenants(tenant_id);
+ALTER TABLE products
+	ALTER COLUMN tenant_id DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS products_tenant_idx ON products (tenant_id);
+
+ALTER TABLE sales
+	ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL DEFAULT '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d' REFERENCES tenants(tenant_id);
+ALTER TABLE sales
+	ALTER COLUMN tenant_id DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS sales_tenant_idx ON sales (tenant_id);
+
+ALTER TABLE audit_log
+	ADD COLUMN IF NOT EXISTS tenant_id UUID;
+UPDATE audit_log SET tenant_id = '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d' WHERE tenant_id IS NULL;
+CREATE INDEX IF NOT EXISTS audit_log_tenant_idx ON audit_log (tenant_id, date_created);
+
+INSERT INTO roles (name, description) VALUES
+	('SUPER_ADMIN', 'Manage tenants and service wide settings')
+	ON CONFLICT DO NOTHING;
+
+-- Version: 2.7
+-- Description: Create table api_keys
+CREATE TABLE IF NOT EXISTS api_keys (
+	key_id         UUID,
+	user_id        UUID NOT NULL,
+	tenant_id      UUID NOT NULL REFERENCES tenants(tenant_id),
+	name           TEXT NOT NULL,
+	prefix         TEXT NOT NULL,
+	key_hash       TEXT NOT NULL UNIQUE,
+	roles          TEXT[] NOT NULL DEFAULT '{}',
+	permissions    TEXT[] NOT NULL DEFAULT '{}',
+	date_expires   TIMESTAMP WITH TIME ZONE,
+	date_last_used TIMESTAMP WITH TIME ZONE,
+	date_revoked   TIMESTAMP WITH TIME ZONE,
+	date_created   TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+	PRIMARY KEY (key_id),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS api_keys_user_idx ON api_keys (user_id);
+
+-- Version: 2.8
+-- Description: Create table oauth_clients
+CREATE TABLE IF NOT EXISTS oauth_clients (
+	client_id    UUID,
+	user_id      UUID NOT NULL,
+	tenant_id    UUID NOT NULL REFERENCES tenants(tenant_id),
+	name         TEXT NOT NULL,
+	secret_hash  TEXT NOT NULL,
+	grant_types  TEXT[] NOT NULL DEFAULT '{}',
+	roles        TEXT[] NOT NULL DEFAULT '{}',
+	date_created TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+	PRIMARY KEY (client_id),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS oauth_clients_user_idx ON oauth_clients (user_id);
+
+-- Version: 2.9
+-- Description: Only super admins manage roles, which are shared by all tenants
+DELETE FROM role_permissions WHERE role_name = 'ADMIN' AND permission = 'roles:write';
+
+INSERT INTO role_permissions (role_name, permission) VALUES
+	('SUPER_ADMIN', 'roles:write')
+	ON CONFLICT DO NOTHING;
+
+-- Version: 3.0
+-- Description: Grant super admins the product and sale permissions
+INSERT INTO role_permissions (role_name, permission) VALUES
+	('SUPER_ADMIN', 'products:read'),
+	('SUPER_ADMIN', 'products:write'),
+	('SUPER_ADMIN', 'sales:read'),
+	('SUPER_ADMIN', 'sales:write')
+	ON CONFLICT DO NOTHING;
+
+-- Version: 3.1
+-- Description: Index revoked_tokens by expiry for pruning
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_idx ON revoked_tokens (date_expires);
@@ -1,26 +1,26 @@
 -- Seed data for users
-INSERT INTO users (user_id, name, email, roles, password_hash, date_created, date_updated) VALUES
-	('a1f37266-1111-4006-984f-9325122678a1', 'Admin Gopher', 'admin@example.com', '{ADMIN,USER}', '$2a$10$1ggfMVZV6Js0ybvJufLRUOWHS5f6KneuP0XwwHpJ8L8ipdry9f2/a', CURRENT_DATE, CURRENT_DATE),
-	('b2e48272-2222-4106-888f-9325122678b2', 'User Gopher1', 'user1@example.com', 'USER', '$2a$10$9/XASPKBbJKVfCAZKDH.UuhsuALDr5vVm6VrYA9VFR8rccK86C1hW', CURRENT_DATE, CURRENT_DATE),
-	('c3d59378-3333-4206-777f-9325122678c3', 'User Gopher2', 'user2@example.com', 'USER', '$2a$10$9/XASPKBbJKVfCAZKDH.UuhsuALDr5vVm6VrYA9VFR8rccK86C1hW', CURRENT_DATE, CURRENT_DATE),
-	('d4c60484-4444-4306-666f-9325122678d4', 'User Gopher3', 'user@example.com', 'USER', '$2y$10$q0ak1CyWW0uMdkT748qVzu.EYeQvNjQF9A35J9IN1LvctwTkRshl6', CURRENT_DATE, CURRENT_DATE),
-	('e5b71590-5555-4406-555f-9325122678e5', 'User Gopher4', 'admin@example.com', 'ADMIN', '$2y$10$q0ak1CyWW0uMdkT748qVzu.EYeQvNjQF9A35J9IN1LvctwTkRshl6', CURRENT_DATE, CURRENT_DATE)
+INSERT INTO users (user_id, tenant_id, name, email, roles, password_hash, date_created, date_updated) VALUES
+	('a1f37266-1111-4006-984f-9325122678a1', '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d', 'Admin Gopher', 'admin@example.com', '{SUPER_ADMIN,ADMIN,USER}', '$2a$10$1ggfMVZV6Js0ybvJufLRUOWHS5f6KneuP0XwwHpJ8L8ipdry9f2/a', CURRENT_DATE, CURRENT_DATE),
+	('b2e48272-2222-4106-888f-9325122678b2', '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d', 'User Gopher1', 'user1@example.com', '{USER}', '$2a$10$9/XASPKBbJKVfCAZKDH.UuhsuALDr5vVm6VrYA9VFR8rccK86C1hW', CURRENT_DATE, CURRENT_DATE),
+	('c3d59378-3333-4206-777f-9325122678c3', '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d', 'User Gopher2', 'user2@example.com', '{USER}', '$2a$10$9/XASPKBbJKVfCAZKDH.UuhsuALDr5vVm6VrYA9VFR8rccK86C1hW', CURRENT_DATE, CURRENT_DATE),
+	('d4c60484-4444-4306-666f-9325122678d4', '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d', 'User Gopher3', 'user@example.com', '{USER}', '$2y$10$q0ak1CyWW0uMdkT748qVzu.EYeQvNjQF9A35J9IN1LvctwTkRshl6', CURRENT_DATE, CURRENT_DATE),
+	('e5b71590-5555-4406-555f-9325122678e5', '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d', 'User Gopher4', 'admin@example.com', '{ADMIN}', '$2y$10$q0ak1CyWW0uMdkT748qVzu.EYeQvNjQF9A35J9IN1LvctwTkRshl6', CURRENT_DATE, CURRENT_DATE)
 ON CONFLICT DO NOTHING;
 
 -- Seed data for products
-INSERT INTO products (product_id, name, cost, quantity, user_id, date_created, date_updated) VALUES
-	('f6a82696-6666-4506-444f-9325122678f6', 'Laptop', 1000, 10, 'a1f37266-1111-4006-984f-9325122678a1', CURRENT_DATE, CURRENT_DATE),
-	('b2e48272-7777-4606-333f-9325122678b2', 'Mobile', 500, 15, 'b2e48272-2222-4106-888f-9325122678b2', CURRENT_DATE, CURRENT_DATE),
-	('c3d59378-8888-4706-222f-9325122678c3', 'Headset', 250, 50, 'c3d59378-3333-4206-777f-9325122678c3', CURRENT_DATE, CURRENT_DATE),
-	('d4c60484-9999-4806-111f-9325122678d4', 'Keyboard', 150, 20, 'd4c60484-4444-4306-666f-9325122678d4', CURRENT_DATE, CURRENT_DATE),
-	('e5b71590-a1a1-4906-a1a1-9325122678e5', 'Mouse', 50, 30, 'e5b71590-5555-4406-555f-9325122678e5', CURRENT_DATE, CURRENT_DATE)
+INSERT INTO products (product_id, tenant_id, name, cost, quantity, user_id, date_created, date_updated) VALUES
+	('f6a82696-6666-4506-444f-9325122678f6', '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d', 'Laptop', 1000, 10, 'a1f37266-1111-4006-984f-9325122678a1', CURRENT_DATE, CURRENT_DATE),
+	('b2e48272-7777-4606-333f-9325122678b2', '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d', 'Mobile', 500, 15, 'b2e48272-2222-4106-888f-9325122678b2', CURRENT_DATE, CURRENT_DATE),
+	('c3d59378-8888-4706-222f-9325122678c3', '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d', 'Headset', 250, 50, 'c3d59378-3333-4206-777f-9325122678c3', CURRENT_DATE, CURRENT_DATE),
+	('d4c60484-9999-4806-111f-9325122678d4', '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d', 'Keyboard', 150, 20, 'd4c60484-4444-4306-666f-9325122678d4', CURRENT_DATE, CURRENT_DATE),
+	('e5b71590-a1a1-4906-a1a1-9325122678e5', '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d', 'Mouse', 50, 30, 'e5b71590-5555-4406-555f-9325122678e5', CURRENT_DATE, CURRENT_DATE)
 ON CONFLICT DO NOTHING;
 
 -- Seed data for sales
-INSERT INTO sales (sale_id, user_id, product_id, quantity, paid, date_created, date_updated) VALUES
-	('f1a82696-a1a1-4506-a1a1-9325122678f6', 'a1f37266-1111-4006-984f-9325122678a1', 'f6a82696-6666-4506-444f-9325122678f6', 2, 2000, CURRENT_DATE, CURRENT_DATE),
-	('b2e48272-b2b2-5106-b2b2-9325122678b2', 'b2e48272-2222-4106-888f-9325122678b2', 'b2e48272-7777-4606-333f-9325122678b2', 3, 1500, CURRENT_DATE, CURRENT_DATE),
-	('c3d59378-c3c3-5206-c3c3-9325122678c3', 'c3d59378-3333-4206-777f-9325122678c3', 'c3d59378-8888-4706-222f-9325122678c3', 1, 750, CURRENT_DATE, CURRENT_DATE),
-	('d4c60484-d4d4-5306-d4d4-9325122678d4', 'd4c60484-4444-4306-666f-9325122678d4', 'd4c60484-9999-4806-111f-9325122678d4', 4, 1000, CURRENT_DATE, CURRENT_DATE),
-	('e5b71590-e5e5-5406-e5e5-9325122678e5', 'e5b71590-5555-4406-555f-9325122678e5', 'e5b71590-a1a1-4906-a1a1-9325122678e5', 5, 500, CURRENT_DATE, CURRENT_DATE)
+INSERT INTO sales (sale_id, tenant_id, user_id, product_id, quantity, paid, date_created, date_updated) VALUES
+	('f1a82696-a1a1-4506-a1a1-9325122678f6', '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d', 'a1f37266-1111-4006-984f-9325122678a1', 'f6a82696-6666-4506-444f-9325122678f6', 2, 2000, CURRENT_DATE, CURRENT_DATE),
+	('b2e48272-b2b2-5106-b2b2-9325122678b2', '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d', 'b2e48272-2222-4106-888f-9325122678b2', 'b2e48272-7777-4606-333f-9325122678b2', 3, 1500, CURRENT_DATE, CURRENT_DATE),
+	('c3d59378-c3c3-5206-c3c3-9325122678c3', '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d', 'c3d59378-3333-4206-777f-9325122678c3', 'c3d59378-8888-4706-222f-9325122678c3', 1, 750, CURRENT_DATE, CURRENT_DATE),
+	('d4c60484-d4d4-5306-d4d4-9325122678d4', '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d', 'd4c60484-4444-4306-666f-9325122678d4', 'd4c60484-9999-4806-111f-9325122678d4', 4, 1000, CURRENT_DATE, CURRENT_DATE),
+	('e5b71590-e5e5-5406-e5e5-9325122678e5', '0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d', 'e5b71590-5555-4406-555f-9325122678e5', 'e5b71590-a1a1-4906-a1a1-9325122678e5', 5, 500, CURRENT_DATE, CURRENT_DATE)
 ON CONFLICT DO NOTHING;
@@ -0,0 +1,295 @@
+// Package apikey stores the API keys users create for machine clients.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/policy"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create issues a key for the user of the claims and returns the raw key to
+// hand to the client. The key can not grant more than the claims hold, so a
+// client authenticated by a scoped or expiring key can not widen its scope or
+// outlive its own key with a new one.
+func (s Store) Create(ctx context.Context, claims auth.Claims, nk NewAPIKey, now time.Time) (APIKey, string, error) {
+
+	if err := validate.Check(nk); err != nil {
+		return APIKey{}, "", fmt.Errorf("validating data: %w", err)
+	}
+
+	if !policy.Allowed(claims, policy.APIKeyCreate, policy.Resource{OwnerID: claims.Subject, TenantID: claims.TenantID}) {
+		return APIKey{}, "", database.ErrForbidden
+	}
+
+	for _, role := range nk.Roles {
+		if !claims.Authorized(role) {
+			return APIKey{}, "", ErrScope
+		}
+	}
+	if len(claims.Permissions) > 0 {
+		if len(nk.Permissions) == 0 {
+			return APIKey{}, "", ErrScope
+		}
+		for _, perm := range nk.Permissions {
+			if !hasPermission(claims.Permissions, perm) {
+				return APIKey{}, "", ErrScope
+			}
+		}
+	}
+
+	if claims.APIKey && claims.ExpiresAt != nil {
+		if nk.DateExpires == nil || nk.DateExpires.After(claims.ExpiresAt.Time) {
+			return APIKey{}, "", ErrScope
+		}
+	}
+
+	if nk.DateExpires != nil && !nk.DateExpires.After(now) {
+		return APIKey{}, "", fmt.Errorf("validating data: %w", &validate.FieldErrors{{Field: "date_expires", Error: "must be in the future"}})
+	}
+
+	prefix, raw, err := generate()
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("generating api key: %w", err)
+	}
+
+	key := APIKey{
+		ID:          validate.GenerateID(),
+		UserID:      claims.Subject,
+		TenantID:    claims.TenantID,
+		Name:        nk.Name,
+		Prefix:      prefix,
+		KeyHash:     hash(raw),
+		Roles:       roleNames(nk.Roles),
+		Permissions: permissionNames(nk.Permissions),
+		DateExpires: nk.DateExpires,
+		DateCreated: now,
+	}
+
+	const q = `
+	INSERT INTO api_keys
+		(key_id, user_id, tenant_id, name, prefix, key_hash, roles, permissions, date_expires, date_created)
+	VALUES
+		(:key_id, :user_id, :tenant_id, :name, :prefix, :key_hash, :roles, :permissions, :date_expires, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, key); err != nil {
+		return APIKey{}, "", fmt.Errorf("inserting api key: %w", err)
+	}
+
+	return key, raw, nil
+}
+
+// QueryByUserID returns the keys of the user, revoked and expired ones
+// included, newest first.
+func (s Store) QueryByUserID(ctx context.Context, claims auth.Claims, userID string) ([]APIKey, error) {
+
+	if err := validate.CheckID(userID); err != nil {
+		return nil, database.ErrInvalidID
+	}
+
+	if !policy.Allowed(claims, policy.APIKeyQuery, policy.Resource{OwnerID: userID, TenantID: claims.TenantID}) {
+		return nil, database.ErrForbidden
+	}
+
+	data := struct {
+		UserID   string `db:"user_id"`
+		TenantID string `db:"tenant_id"`
+	}{
+		UserID:   userID,
+		TenantID: claims.TenantID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		api_keys
+	WHERE
+		user_id = :user_id AND
+		tenant_id = :tenant_id
+	ORDER BY
+		date_created DESC, key_id`
+
+	var keys []APIKey
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &keys); err != nil {
+		return nil, fmt.Errorf("selecting api keys userID[%s]: %w", userID, err)
+	}
+
+	return keys, nil
+}
+
+// Revoke stops the key of the user from being accepted. Revoking a revoked key
+// keeps the original date. It returns the key as it was before.
+func (s Store) Revoke(ctx context.Context, claims auth.Claims, userID string, keyID string, now time.Time) (APIKey, error) {
+
+	if err := validate.CheckID(userID); err != nil {
+		return APIKey{}, database.ErrInvalidID
+	}
+	if err := validate.CheckID(keyID); err != nil {
+		return APIKey{}, database.ErrInvalidID
+	}
+
+	data := struct {
+		KeyID       string    `db:"key_id"`
+		UserID      string    `db:"user_id"`
+		TenantID    string    `db:"tenant_id"`
+		DateRevoked time.Time `db:"date_revoked"`
+	}{
+		KeyID:       keyID,
+		UserID:      userID,
+		TenantID:    claims.TenantID,
+		DateRevoked: now,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		api_keys
+	WHERE
+		key_id = :key_id AND
+		user_id = :user_id AND
+		tenant_id = :tenant_id`
+
+	var key APIKey
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &key); err != nil {
+		if err == database.ErrNotFound {
+			return APIKey{}, database.ErrNotFound
+		}
+		return APIKey{}, fmt.Errorf("selecting api key keyID[%s]: %w", keyID, err)
+	}
+
+	if !policy.Allowed(claims, policy.APIKeyRevoke, key.resource()) {
+		return APIKey{}, database.ErrForbidden
+	}
+
+	const u = `
+	UPDATE
+		api_keys
+	SET
+		"date_revoked" = COALESCE(date_revoked, :date_revoked)
+	WHERE
+		key_id = :key_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, u, data); err != nil {
+		return APIKey{}, fmt.Errorf("revoking api key keyID[%s]: %w", keyID, err)
+	}
+
+	return key, nil
+}
+
+// Authenticate returns the key matching the raw key and records that it was
+// used. Revoked keys are reported as not found and expired keys return
+// ErrExpired.
+func (s Store) Authenticate(ctx context.Context, raw string, now time.Time) (APIKey, error) {
+
+	data := struct {
+		KeyHash string    `db:"key_hash"`
+		Now     time.Time `db:"now"`
+	}{
+		KeyHash: hash(raw),
+		Now:     now,
+	}
+
+	const q = `
+	UPDATE
+		api_keys
+	SET
+		"date_last_used" = :now
+	WHERE
+		key_hash = :key_hash AND
+		date_revoked IS NULL
+	RETURNING
+		*`
+
+	var key APIKey
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &key); err != nil {
+		if err == database.ErrNotFound {
+			return APIKey{}, database.ErrNotFound
+		}
+		return APIKey{}, fmt.Errorf("selecting api key: %w", err)
+	}
+
+	if key.DateExpires != nil && !now.Before(*key.DateExpires) {
+		return APIKey{}, ErrExpired
+	}
+
+	return key, nil
+}
+
+// resource describes the key to the authorization policy.
+func (k APIKey) resource() policy.Resource {
+	return policy.Resource{
+		OwnerID:  k.UserID,
+		TenantID: k.TenantID,
+	}
+}
+
+// generate returns a new key in the form sk_<prefix>_<secret> together with
+// the part of it that is shown when listing keys.
+func generate() (string, string, error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	prefix := Prefix + "_" + hex.EncodeToString(id)
+	return prefix, prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func hasPermission(perms []auth.Permission, perm auth.Permission) bool {
+	for _, p := range perms {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+func roleNames(roles []auth.Role) pq.StringArray {
+	names := make(pq.StringArray, len(roles))
+	for i, role := range roles {
+		names[i] = role.Name()
+	}
+	return names
+}
+
+func permissionNames(perms []auth.Permission) pq.StringArray {
+	names := make(pq.StringArray, len(perms))
+	for i, perm := range perms {
+		names[i] = perm.Name()
+	}
+	return names
+}
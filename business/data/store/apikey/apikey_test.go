@@ -0,0 +1,123 @@
+package apikey_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/apikey"
+	"github.com/Avyukth/service3-clone/business/data/store/tenant"
+	"github.com/Avyukth/service3-clone/business/data/tests"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var dbc = tests.DBContainer{
+	Image: "postgres:latest",
+	Port:  "5432",
+	Args:  []string{"-e", "POSTGRES_PASSWORD=postgres"},
+}
+
+func TestAPIKey(t *testing.T) {
+
+	log, db, teardown := tests.NewUnit(t, dbc)
+
+	t.Cleanup(teardown)
+
+	store := apikey.NewStore(log, db)
+
+	t.Log("Given the need to work with api keys.")
+	{
+		testID := 0
+
+		t.Logf("\t Test %d:\tWhen handling a single api key.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+			claims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject: "b2e48272-2222-4106-888f-9325122678b2",
+				},
+				Roles:    []auth.Role{auth.RoleUser},
+				TenantID: tenant.DefaultID,
+			}
+
+			nk := apikey.NewAPIKey{
+				Name:  "nightly export",
+				Roles: []auth.Role{auth.RoleAdmin},
+			}
+			if _, _, err := store.Create(ctx, claims, nk, now); !errors.Is(err, apikey.ErrScope) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to grant a role the user does not hold : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to grant a role the user does not hold.", tests.Success, testID)
+
+			expires := now.Add(time.Hour)
+			nk.Roles = []auth.Role{auth.RoleUser}
+			nk.Permissions = []auth.Permission{auth.PermProductsRead}
+			nk.DateExpires = &expires
+
+			key, raw, err := store.Create(ctx, claims, nk, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create an api key : %s.", tests.Failed, testID, err)
+			}
+			if !strings.HasPrefix(raw, key.Prefix+"_") {
+				t.Fatalf("\t%s\tTest %d:\tShould start the key with its prefix : %s, %s.", tests.Failed, testID, key.Prefix, raw)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create an api key.", tests.Success, testID)
+
+			got, err := store.Authenticate(ctx, raw, now)
+			if err != nil || got.ID != key.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate with the key : %v, %v.", tests.Failed, testID, got, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to authenticate with the key.", tests.Success, testID)
+
+			keyClaims := claims
+			keyClaims.APIKey = true
+			keyClaims.ExpiresAt = jwt.NewNumericDate(expires)
+			keyClaims.Permissions = []auth.Permission{auth.PermUsersWrite, auth.PermProductsRead}
+
+			nk.DateExpires = nil
+			if _, _, err := store.Create(ctx, keyClaims, nk, now); !errors.Is(err, apikey.ErrScope) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to create a key without expiry from an expiring key : %v.", tests.Failed, testID, err)
+			}
+			later := expires.Add(time.Hour)
+			nk.DateExpires = &later
+			if _, _, err := store.Create(ctx, keyClaims, nk, now); !errors.Is(err, apikey.ErrScope) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to create a key outliving the expiring key : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to create a key outliving the expiring key.", tests.Success, testID)
+
+			if _, err := store.Authenticate(ctx, raw, expires); !errors.Is(err, apikey.ErrExpired) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to authenticate with an expired key : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to authenticate with an expired key.", tests.Success, testID)
+
+			other := claims
+			other.Subject = "c3d59378-3333-4206-777f-9325122678c3"
+			if _, err := store.Revoke(ctx, other, claims.Subject, key.ID, now); !errors.Is(err, database.ErrForbidden) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to revoke another user's key : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to revoke another user's key.", tests.Success, testID)
+
+			if _, err := store.Revoke(ctx, claims, claims.Subject, key.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to revoke the key : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to revoke the key.", tests.Success, testID)
+
+			if _, err := store.Authenticate(ctx, raw, now); !errors.Is(err, database.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to authenticate with a revoked key : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to authenticate with a revoked key.", tests.Success, testID)
+
+			keys, err := store.QueryByUserID(ctx, claims, claims.Subject)
+			if err != nil || len(keys) != 1 || keys[0].DateRevoked == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould list the revoked key : %v, %v.", tests.Failed, testID, keys, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould list the revoked key.", tests.Success, testID)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package apikey
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/lib/pq"
+)
+
+// Prefix starts every API key so keys are easy to recognise, for example by
+// secret scanners.
+const Prefix = "sk"
+
+var (
+	ErrScope   = errors.New("api key can not grant more than its owner holds")
+	ErrExpired = errors.New("api key expired")
+)
+
+// APIKey is a long lived credential a user hands to a machine client. Only the
+// hash of the key is stored, the prefix is kept to tell keys apart.
+type APIKey struct {
+	ID           string         `db:"key_id" json:"id"`
+	UserID       string         `db:"user_id" json:"user_id"`
+	TenantID     string         `db:"tenant_id" json:"tenant_id"`
+	Name         string         `db:"name" json:"name"`
+	Prefix       string         `db:"prefix" json:"prefix"`
+	KeyHash      string         `db:"key_hash" json:"-"`
+	Roles        pq.StringArray `db:"roles" json:"roles"`
+	Permissions  pq.StringArray `db:"permissions" json:"permissions"`
+	DateExpires  *time.Time     `db:"date_expires" json:"date_expires"`
+	DateLastUsed *time.Time     `db:"date_last_used" json:"date_last_used"`
+	DateRevoked  *time.Time     `db:"date_revoked" json:"date_revoked"`
+	DateCreated  time.Time      `db:"date_created" json:"date_created"`
+}
+
+// NewAPIKey is what a user provides to create a key for themselves. The roles
+// must be a subset of the roles of the user. Without permissions the key has
+// every permission its roles grant, without an expiry it is valid until it is
+// revoked.
+type NewAPIKey struct {
+	Name        string            `json:"name" validate:"required"`
+	Roles       []auth.Role       `json:"roles" validate:"required,min=1"`
+	Permissions []auth.Permission `json:"permissions"`
+	DateExpires *time.Time        `json:"date_expires"`
+}
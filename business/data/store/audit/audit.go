@@ -0,0 +1,184 @@
+// Package audit records who changed what. Entries are written through the
+// context so they share the transaction of the change they describe.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/Avyukth/service3-clone/foundation/web"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Record writes an audit entry for a change made by the subject of the claims.
+// The entry belongs to the tenant of the claims. Before and after are stored
+// as JSON, a nil value is stored as NULL.
+func (s Store) Record(ctx context.Context, claims auth.Claims, entity string, entityID string, action string, before any, after any, now time.Time) error {
+
+	b, err := marshal(before)
+	if err != nil {
+		return fmt.Errorf("encoding before: %w", err)
+	}
+	a, err := marshal(after)
+	if err != nil {
+		return fmt.Errorf("encoding after: %w", err)
+	}
+
+	var actorID *string
+	if claims.Subject != "" {
+		actorID = &claims.Subject
+	}
+
+	var tenantID *string
+	if claims.TenantID != "" {
+		tenantID = &claims.TenantID
+	}
+
+	// JSON is sent as text, lib/pq would send a []byte as bytea which the
+	// JSONB columns do not accept.
+	data := struct {
+		ID          string    `db:"audit_id"`
+		TenantID    *string   `db:"tenant_id"`
+		ActorID     *string   `db:"actor_id"`
+		TraceID     string    `db:"trace_id"`
+		Entity      string    `db:"entity"`
+		EntityID    string    `db:"entity_id"`
+		Action      string    `db:"action"`
+		Before      *string   `db:"before"`
+		After       *string   `db:"after"`
+		DateCreated time.Time `db:"date_created"`
+	}{
+		ID:          validate.GenerateID(),
+		TenantID:    tenantID,
+		ActorID:     actorID,
+		TraceID:     web.GetTraceID(ctx),
+		Entity:      entity,
+		EntityID:    entityID,
+		Action:      action,
+		Before:      b,
+		After:       a,
+		DateCreated: now,
+	}
+
+	const q = `
+	INSERT INTO audit_log
+		(audit_id, tenant_id, actor_id, trace_id, entity, entity_id, action, before, after, date_created)
+	VALUES
+		(:audit_id, :tenant_id, :actor_id, :trace_id, :entity, :entity_id, :action, :before, :after, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("inserting audit entity[%s] entityID[%s]: %w", entity, entityID, err)
+	}
+
+	return nil
+}
+
+// Query returns a page of the audit entries of the tenant of the claims
+// matching the filter, newest first.
+func (s Store) Query(ctx context.Context, claims auth.Claims, filter QueryFilter, pageNumber int, rowsPerPage int) ([]Audit, error) {
+
+	if err := validate.Check(filter); err != nil {
+		return nil, fmt.Errorf("validating filter: %w", err)
+	}
+
+	data := map[string]interface{}{
+		"tenant_id":     claims.TenantID,
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		audit_log`
+
+	wc := append([]string{"tenant_id = :tenant_id"}, filterClauses(filter, data)...)
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(`
+	WHERE
+		`)
+	buf.WriteString(strings.Join(wc, " AND\n\t\t"))
+	buf.WriteString(`
+	ORDER BY
+		date_created DESC, audit_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`)
+
+	var adts []Audit
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &adts); err != nil {
+		return nil, fmt.Errorf("selecting audit log: %w", err)
+	}
+
+	return adts, nil
+}
+
+func filterClauses(filter QueryFilter, data map[string]interface{}) []string {
+	var wc []string
+
+	if filter.ActorID != nil {
+		data["actor_id"] = *filter.ActorID
+		wc = append(wc, "actor_id = :actor_id")
+	}
+
+	if filter.Entity != nil {
+		data["entity"] = *filter.Entity
+		wc = append(wc, "entity = :entity")
+	}
+
+	if filter.EntityID != nil {
+		data["entity_id"] = *filter.EntityID
+		wc = append(wc, "entity_id = :entity_id")
+	}
+
+	if filter.Action != nil {
+		data["action"] = *filter.Action
+		wc = append(wc, "action = :action")
+	}
+
+	if filter.StartDate != nil {
+		data["start_date"] = filter.StartDate.UTC()
+		wc = append(wc, "date_created >= :start_date")
+	}
+
+	if filter.EndDate != nil {
+		data["end_date"] = filter.EndDate.UTC()
+		wc = append(wc, "date_created <= :end_date")
+	}
+
+	return wc
+}
+
+func marshal(v any) (*string, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	str := string(data)
+	return &str, nil
+}
@@ -0,0 +1,77 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/audit"
+	"github.com/Avyukth/service3-clone/business/data/store/tenant"
+	"github.com/Avyukth/service3-clone/business/data/tests"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var dbc = tests.DBContainer{
+	Image: "postgres:latest",
+	Port:  "5432",
+	Args:  []string{"-e", "POSTGRES_PASSWORD=postgres"},
+}
+
+func TestAudit(t *testing.T) {
+
+	log, db, teardown := tests.NewUnit(t, dbc)
+
+	t.Cleanup(teardown)
+
+	store := audit.NewStore(log, db)
+
+	t.Log("Given the need to record changes.")
+	{
+		testID := 0
+
+		t.Logf("\t Test %d:\tWhen recording an update.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+			const entityID = "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+
+			claims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject: "5cf37266-3473-4006-984f-9325122678b7",
+				},
+				Roles:    []auth.Role{auth.RoleAdmin},
+				TenantID: tenant.DefaultID,
+			}
+
+			before := map[string]string{"name": "Comic Books"}
+			after := map[string]string{"name": "Graphic Novels"}
+
+			if err := store.Record(ctx, claims, audit.EntityProduct, entityID, audit.ActionUpdate, before, after, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to record the change : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to record the change.", tests.Success, testID)
+
+			entity := audit.EntityProduct
+			id := entityID
+			adts, err := store.Query(ctx, claims, audit.QueryFilter{Entity: &entity, EntityID: &id}, 1, 10)
+			if err != nil || len(adts) != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to find the entry : %v, %v.", tests.Failed, testID, adts, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to find the entry.", tests.Success, testID)
+
+			adt := adts[0]
+			if adt.ActorID == nil || *adt.ActorID != claims.Subject || adt.Action != audit.ActionUpdate {
+				t.Fatalf("\t%s\tTest %d:\tShould record the actor and action : %+v.", tests.Failed, testID, adt)
+			}
+			t.Logf("\t%s\tTest %d:\tShould record the actor and action.", tests.Success, testID)
+
+			var got map[string]string
+			if err := json.Unmarshal(adt.After, &got); err != nil || got["name"] != after["name"] {
+				t.Fatalf("\t%s\tTest %d:\tShould record the entity after the change : %s, %v.", tests.Failed, testID, adt.After, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould record the entity after the change.", tests.Success, testID)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Entities and actions recorded in the audit log.
+const (
+	EntityUser    = "user"
+	EntityProduct = "product"
+	EntitySale    = "sale"
+	EntityRole    = "role"
+	EntityTenant  = "tenant"
+	EntityAPIKey  = "api_key"
+	EntityClient  = "oauth_client"
+
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+	ActionVerify  = "verify"
+	ActionRevoke  = "revoke"
+
+	ActionPasswordChange = "password_change"
+	ActionPasswordReset  = "password_reset"
+)
+
+// Audit is a single recorded change. Before and After hold the JSON form of
+// the entity and are empty for creates and deletes respectively.
+type Audit struct {
+	ID          string          `db:"audit_id" json:"id"`
+	TenantID    *string         `db:"tenant_id" json:"tenant_id"`
+	ActorID     *string         `db:"actor_id" json:"actor_id"`
+	TraceID     string          `db:"trace_id" json:"trace_id"`
+	Entity      string          `db:"entity" json:"entity"`
+	EntityID    string          `db:"entity_id" json:"entity_id"`
+	Action      string          `db:"action" json:"action"`
+	Before      json.RawMessage `db:"before" json:"before,omitempty"`
+	After       json.RawMessage `db:"after" json:"after,omitempty"`
+	DateCreated time.Time       `db:"date_created" json:"date_created"`
+}
+
+// QueryFilter holds the available fields the audit log can be filtered on.
+type QueryFilter struct {
+	ActorID   *string    `json:"actor_id" validate:"omitempty,uuid"`
+	Entity    *string    `json:"entity"`
+	EntityID  *string    `json:"entity_id"`
+	Action    *string    `json:"action"`
+	StartDate *time.Time `json:"start_date"`
+	EndDate   *time.Time `json:"end_date"`
+}
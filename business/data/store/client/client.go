@@ -0,0 +1,243 @@
+// Package client stores the OAuth2 clients users register with the
+// authorization server.
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/policy"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create registers a client for the user of the claims and returns the raw
+// secret to hand to the client. Callers holding a scoped API key can not
+// register clients, since the client would not carry the scope.
+func (s Store) Create(ctx context.Context, claims auth.Claims, nc NewClient, now time.Time) (Client, string, error) {
+
+	if err := validate.Check(nc); err != nil {
+		return Client{}, "", fmt.Errorf("validating data: %w", err)
+	}
+
+	if !policy.Allowed(claims, policy.ClientCreate, policy.Resource{OwnerID: claims.Subject, TenantID: claims.TenantID}) {
+		return Client{}, "", database.ErrForbidden
+	}
+
+	if len(claims.Permissions) > 0 {
+		return Client{}, "", ErrScope
+	}
+	for _, role := range nc.Roles {
+		if !claims.Authorized(role) {
+			return Client{}, "", ErrScope
+		}
+	}
+
+	clt := Client{
+		ID:          validate.GenerateID(),
+		UserID:      claims.Subject,
+		TenantID:    claims.TenantID,
+		Name:        nc.Name,
+		GrantTypes:  pq.StringArray(nc.GrantTypes),
+		Roles:       roleNames(nc.Roles),
+		DateCreated: now,
+	}
+
+	if clt.Allows(GrantClientCredentials) && len(clt.Roles) == 0 {
+		return Client{}, "", fmt.Errorf("validating data: %w", &validate.FieldErrors{{Field: "roles", Error: "roles is required for the client_credentials grant"}})
+	}
+
+	secret, err := generate()
+	if err != nil {
+		return Client{}, "", fmt.Errorf("generating client secret: %w", err)
+	}
+	clt.SecretHash = hash(secret)
+
+	const q = `
+	INSERT INTO oauth_clients
+		(client_id, user_id, tenant_id, name, secret_hash, grant_types, roles, date_created)
+	VALUES
+		(:client_id, :user_id, :tenant_id, :name, :secret_hash, :grant_types, :roles, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, clt); err != nil {
+		return Client{}, "", fmt.Errorf("inserting client: %w", err)
+	}
+
+	return clt, secret, nil
+}
+
+// QueryByUserID returns the clients registered by the user, newest first.
+func (s Store) QueryByUserID(ctx context.Context, claims auth.Claims, userID string) ([]Client, error) {
+
+	if err := validate.CheckID(userID); err != nil {
+		return nil, database.ErrInvalidID
+	}
+
+	if !policy.Allowed(claims, policy.ClientQuery, policy.Resource{OwnerID: userID, TenantID: claims.TenantID}) {
+		return nil, database.ErrForbidden
+	}
+
+	data := struct {
+		UserID   string `db:"user_id"`
+		TenantID string `db:"tenant_id"`
+	}{
+		UserID:   userID,
+		TenantID: claims.TenantID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		oauth_clients
+	WHERE
+		user_id = :user_id AND
+		tenant_id = :tenant_id
+	ORDER BY
+		date_created DESC, client_id`
+
+	var clts []Client
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &clts); err != nil {
+		return nil, fmt.Errorf("selecting clients userID[%s]: %w", userID, err)
+	}
+
+	return clts, nil
+}
+
+// Delete removes the client. Tokens already issued to it stay valid until
+// they expire. It returns the client as it was before.
+func (s Store) Delete(ctx context.Context, claims auth.Claims, clientID string) (Client, error) {
+
+	if err := validate.CheckID(clientID); err != nil {
+		return Client{}, database.ErrInvalidID
+	}
+
+	data := struct {
+		ClientID string `db:"client_id"`
+		TenantID string `db:"tenant_id"`
+	}{
+		ClientID: clientID,
+		TenantID: claims.TenantID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		oauth_clients
+	WHERE
+		client_id = :client_id AND
+		tenant_id = :tenant_id`
+
+	var clt Client
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &clt); err != nil {
+		if err == database.ErrNotFound {
+			return Client{}, database.ErrNotFound
+		}
+		return Client{}, fmt.Errorf("selecting client clientID[%s]: %w", clientID, err)
+	}
+
+	if !policy.Allowed(claims, policy.ClientDelete, clt.resource()) {
+		return Client{}, database.ErrForbidden
+	}
+
+	const d = `
+	DELETE FROM
+		oauth_clients
+	WHERE
+		client_id = :client_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, d, data); err != nil {
+		return Client{}, fmt.Errorf("deleting client clientID[%s]: %w", clientID, err)
+	}
+
+	return clt, nil
+}
+
+// Authenticate returns the client when the secret matches. Unknown clients
+// and wrong secrets are both reported as not found.
+func (s Store) Authenticate(ctx context.Context, clientID string, secret string) (Client, error) {
+
+	if err := validate.CheckID(clientID); err != nil {
+		return Client{}, database.ErrNotFound
+	}
+
+	data := struct {
+		ClientID string `db:"client_id"`
+	}{
+		ClientID: clientID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		oauth_clients
+	WHERE
+		client_id = :client_id`
+
+	var clt Client
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &clt); err != nil {
+		if err == database.ErrNotFound {
+			return Client{}, database.ErrNotFound
+		}
+		return Client{}, fmt.Errorf("selecting client clientID[%s]: %w", clientID, err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hash(secret)), []byte(clt.SecretHash)) != 1 {
+		return Client{}, database.ErrNotFound
+	}
+
+	return clt, nil
+}
+
+// resource describes the client to the authorization policy.
+func (c Client) resource() policy.Resource {
+	return policy.Resource{
+		OwnerID:  c.UserID,
+		TenantID: c.TenantID,
+	}
+}
+
+func generate() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func roleNames(roles []auth.Role) pq.StringArray {
+	names := make(pq.StringArray, len(roles))
+	for i, role := range roles {
+		names[i] = role.Name()
+	}
+	return names
+}
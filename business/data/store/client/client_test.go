@@ -0,0 +1,101 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/client"
+	"github.com/Avyukth/service3-clone/business/data/store/tenant"
+	"github.com/Avyukth/service3-clone/business/data/tests"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var dbc = tests.DBContainer{
+	Image: "postgres:latest",
+	Port:  "5432",
+	Args:  []string{"-e", "POSTGRES_PASSWORD=postgres"},
+}
+
+func TestClient(t *testing.T) {
+
+	log, db, teardown := tests.NewUnit(t, dbc)
+
+	t.Cleanup(teardown)
+
+	store := client.NewStore(log, db)
+
+	t.Log("Given the need to work with oauth clients.")
+	{
+		testID := 0
+
+		t.Logf("\t Test %d:\tWhen handling a single client.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+			claims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject: "b2e48272-2222-4106-888f-9325122678b2",
+				},
+				Roles:    []auth.Role{auth.RoleUser},
+				TenantID: tenant.DefaultID,
+			}
+
+			nc := client.NewClient{
+				Name:       "reporting",
+				GrantTypes: []string{client.GrantClientCredentials},
+				Roles:      []auth.Role{auth.RoleAdmin},
+			}
+			if _, _, err := store.Create(ctx, claims, nc, now); !errors.Is(err, client.ErrScope) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to grant a role the user does not hold : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to grant a role the user does not hold.", tests.Success, testID)
+
+			nc.Roles = []auth.Role{auth.RoleUser}
+
+			clt, secret, err := store.Create(ctx, claims, nc, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to register a client : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to register a client.", tests.Success, testID)
+
+			got, err := store.Authenticate(ctx, clt.ID, secret)
+			if err != nil || got.ID != clt.ID || !got.Allows(client.GrantClientCredentials) {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate the client : %v, %v.", tests.Failed, testID, got, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to authenticate the client.", tests.Success, testID)
+
+			if _, err := store.Authenticate(ctx, clt.ID, "wrong"); !errors.Is(err, database.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to authenticate with a wrong secret : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to authenticate with a wrong secret.", tests.Success, testID)
+
+			other := claims
+			other.Subject = "c3d59378-3333-4206-777f-9325122678c3"
+			if _, err := store.Delete(ctx, other, clt.ID); !errors.Is(err, database.ErrForbidden) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to delete another user's client : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to delete another user's client.", tests.Success, testID)
+
+			clts, err := store.QueryByUserID(ctx, claims, claims.Subject)
+			if err != nil || len(clts) != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould list the client : %v, %v.", tests.Failed, testID, clts, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould list the client.", tests.Success, testID)
+
+			if _, err := store.Delete(ctx, claims, clt.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete the client : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to delete the client.", tests.Success, testID)
+
+			if _, err := store.Authenticate(ctx, clt.ID, secret); !errors.Is(err, database.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to authenticate a deleted client : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to authenticate a deleted client.", tests.Success, testID)
+		}
+	}
+}
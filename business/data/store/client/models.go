@@ -0,0 +1,51 @@
+package client
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/lib/pq"
+)
+
+// Grant types a client can be registered for.
+const (
+	GrantPassword          = "password"
+	GrantRefreshToken      = "refresh_token"
+	GrantClientCredentials = "client_credentials"
+)
+
+var ErrScope = errors.New("client can not grant more than its owner holds")
+
+// Client is an OAuth2 client registered by a user. Only the hash of the
+// secret is stored. Tokens issued to the client through the client
+// credentials grant act as the user, limited to the roles of the client.
+type Client struct {
+	ID          string         `db:"client_id" json:"client_id"`
+	UserID      string         `db:"user_id" json:"user_id"`
+	TenantID    string         `db:"tenant_id" json:"tenant_id"`
+	Name        string         `db:"name" json:"client_name"`
+	SecretHash  string         `db:"secret_hash" json:"-"`
+	GrantTypes  pq.StringArray `db:"grant_types" json:"grant_types"`
+	Roles       pq.StringArray `db:"roles" json:"roles"`
+	DateCreated time.Time      `db:"date_created" json:"date_created"`
+}
+
+// NewClient is what a user provides to register a client. Roles are only
+// used by the client credentials grant and must be a subset of the roles of
+// the user.
+type NewClient struct {
+	Name       string      `json:"client_name" validate:"required"`
+	GrantTypes []string    `json:"grant_types" validate:"required,min=1,dive,oneof=password refresh_token client_credentials"`
+	Roles      []auth.Role `json:"roles"`
+}
+
+// Allows reports whether the client was registered for the grant type.
+func (c Client) Allows(grant string) bool {
+	for _, g := range c.GrantTypes {
+		if g == grant {
+			return true
+		}
+	}
+	return false
+}
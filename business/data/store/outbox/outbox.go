@@ -0,0 +1,175 @@
+// Package outbox stores domain events in the same transaction as the change
+// that raised them, so an event is published if and only if the change was
+// committed.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/events"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/Avyukth/service3-clone/foundation/web"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// MaxAttempts is how many times publishing an event is tried before it is
+// left in the outbox for an operator to look at.
+const MaxAttempts = 10
+
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Add stores the event for publishing. It should be called within the
+// transaction of the change the event describes.
+func (s Store) Add(ctx context.Context, evt events.Event, now time.Time) error {
+
+	env, err := events.NewEnvelope(validate.GenerateID(), evt, now)
+	if err != nil {
+		return err
+	}
+
+	// The payload is sent as text, lib/pq would send a []byte as bytea which
+	// the JSONB column does not accept.
+	data := struct {
+		ID          string    `db:"event_id"`
+		Name        string    `db:"name"`
+		Payload     string    `db:"payload"`
+		TraceID     string    `db:"trace_id"`
+		DateCreated time.Time `db:"date_created"`
+	}{
+		ID:          env.ID,
+		Name:        env.Name,
+		Payload:     string(env.Payload),
+		TraceID:     web.GetTraceID(ctx),
+		DateCreated: env.OccurredAt,
+	}
+
+	const q = `
+	INSERT INTO outbox
+		(event_id, name, payload, trace_id, date_created)
+	VALUES
+		(:event_id, :name, :payload, :trace_id, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("inserting event[%s]: %w", env.Name, err)
+	}
+
+	return nil
+}
+
+// Pending returns up to limit unpublished events, oldest first. The rows are
+// locked until the transaction ends and rows locked by another relay are
+// skipped, so it must be called within a transaction.
+func (s Store) Pending(ctx context.Context, limit int) ([]events.Envelope, error) {
+
+	data := struct {
+		MaxAttempts int `db:"max_attempts"`
+		Limit       int `db:"limit"`
+	}{
+		MaxAttempts: MaxAttempts,
+		Limit:       limit,
+	}
+
+	const q = `
+	SELECT
+		event_id, name, payload, date_created
+	FROM
+		outbox
+	WHERE
+		date_published IS NULL AND
+		attempts < :max_attempts
+	ORDER BY
+		date_created
+	LIMIT :limit
+	FOR UPDATE SKIP LOCKED`
+
+	var rows []struct {
+		ID          string    `db:"event_id"`
+		Name        string    `db:"name"`
+		Payload     []byte    `db:"payload"`
+		DateCreated time.Time `db:"date_created"`
+	}
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &rows); err != nil {
+		return nil, fmt.Errorf("selecting pending events: %w", err)
+	}
+
+	envs := make([]events.Envelope, len(rows))
+	for i, r := range rows {
+		envs[i] = events.Envelope{
+			ID:         r.ID,
+			Name:       r.Name,
+			Payload:    r.Payload,
+			OccurredAt: r.DateCreated,
+		}
+	}
+
+	return envs, nil
+}
+
+// MarkPublished records that the event was handed to every subscriber.
+func (s Store) MarkPublished(ctx context.Context, eventID string, now time.Time) error {
+
+	data := struct {
+		ID            string    `db:"event_id"`
+		DatePublished time.Time `db:"date_published"`
+	}{
+		ID:            eventID,
+		DatePublished: now,
+	}
+
+	const q = `
+	UPDATE
+		outbox
+	SET
+		"attempts" = attempts + 1,
+		"last_error" = NULL,
+		"date_published" = :date_published
+	WHERE
+		event_id = :event_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("marking event[%s] published: %w", eventID, err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed attempt so the event is tried again later.
+func (s Store) MarkFailed(ctx context.Context, eventID string, cause error) error {
+
+	data := struct {
+		ID        string `db:"event_id"`
+		LastError string `db:"last_error"`
+	}{
+		ID:        eventID,
+		LastError: cause.Error(),
+	}
+
+	const q = `
+	UPDATE
+		outbox
+	SET
+		"attempts" = attempts + 1,
+		"last_error" = :last_error
+	WHERE
+		event_id = :event_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("marking event[%s] failed: %w", eventID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/policy"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		db:  db,
+	}
+}
+
+func (s Store) Create(ctx context.Context, claims auth.Claims, np NewProduct, now time.Time) (Product, error) {
+
+	if err := validate.Check(np); err != nil {
+		return Product{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	prd := Product{
+		ID:          validate.GenerateID(),
+		TenantID:    claims.TenantID,
+		Name:        np.Name,
+		Cost:        np.Cost,
+		Quantity:    np.Quantity,
+		UserID:      claims.Subject,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	const q = `
+	INSERT INTO products
+		(product_id, tenant_id, name, cost, quantity, user_id, date_created, date_updated)
+	VALUES
+		(:product_id, :tenant_id, :name, :cost, :quantity, :user_id, :date_created, :date_updated)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, prd); err != nil {
+		return Product{}, fmt.Errorf("inserting product: %w", err)
+	}
+
+	return prd, nil
+}
+
+func (s Store) Update(ctx context.Context, claims auth.Claims, productID string, up UpdateProduct, now time.Time) error {
+
+	if err := validate.CheckID(productID); err != nil {
+		return database.ErrInvalidID
+	}
+
+	if err := validate.Check(up); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	prd, err := s.QueryByID(ctx, claims, productID)
+	if err != nil {
+		return fmt.Errorf("updating product productID[%s]: %w", productID, err)
+	}
+
+	if !policy.Allowed(claims, policy.ProductUpdate, prd.resource()) {
+		return database.ErrForbidden
+	}
+
+	// Only the fields provided are written so an update racing a sale can not
+	// put back the stock the sale took.
+	data := struct {
+		ProductID   string    `db:"product_id"`
+		TenantID    string    `db:"tenant_id"`
+		Name        *string   `db:"name"`
+		Cost        *int      `db:"cost"`
+		Quantity    *int      `db:"quantity"`
+		DateUpdated time.Time `db:"date_updated"`
+	}{
+		ProductID:   prd.ID,
+		TenantID:    prd.TenantID,
+		Name:        up.Name,
+		Cost:        up.Cost,
+		Quantity:    up.Quantity,
+		DateUpdated: now,
+	}
+
+	const q = `
+	UPDATE
+		products
+	SET
+		"name" = COALESCE(:name, name),
+		"cost" = COALESCE(:cost, cost),
+		"quantity" = COALESCE(:quantity, quantity),
+		"date_updated" = :date_updated
+	WHERE
+		product_id = :product_id AND
+		tenant_id = :tenant_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("updating product productID[%s]: %w", productID, err)
+	}
+
+	return nil
+}
+
+func (s Store) Delete(ctx context.Context, claims auth.Claims, productID string) error {
+
+	if err := validate.CheckID(productID); err != nil {
+		return database.ErrInvalidID
+	}
+
+	prd, err := s.QueryByID(ctx, claims, productID)
+	if err != nil {
+		return fmt.Errorf("deleting product productID[%s]: %w", productID, err)
+	}
+
+	if !policy.Allowed(claims, policy.ProductDelete, prd.resource()) {
+		return database.ErrForbidden
+	}
+
+	const q = `
+	DELETE FROM
+		products
+	WHERE
+		product_id = :product_id AND
+		tenant_id = :tenant_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, prd); err != nil {
+		return fmt.Errorf("deleting product productID[%s]: %w", productID, err)
+	}
+
+	return nil
+}
+
+// Query returns a page of the products of the tenant of the claims.
+func (s Store) Query(ctx context.Context, claims auth.Claims, pageNumber int, rowsPerPage int) ([]Product, error) {
+
+	data := struct {
+		TenantID    string `db:"tenant_id"`
+		Offset      int    `db:"offset"`
+		RowsPerPage int    `db:"rows_per_page"`
+	}{
+		TenantID:    claims.TenantID,
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		products
+	WHERE
+		tenant_id = :tenant_id
+	ORDER BY
+		product_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var prds []Product
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &prds); err != nil {
+		if err == database.ErrNotFound {
+			return nil, database.ErrNotFound
+		}
+		return nil, fmt.Errorf("selecting products: %w", err)
+	}
+
+	return prds, nil
+}
+
+// QueryByID returns the product from the tenant of the claims. Products of
+// other tenants are reported as not found.
+func (s Store) QueryByID(ctx context.Context, claims auth.Claims, productID string) (Product, error) {
+
+	if err := validate.CheckID(productID); err != nil {
+		return Product{}, database.ErrInvalidID
+	}
+
+	data := struct {
+		ProductID string `db:"product_id"`
+		TenantID  string `db:"tenant_id"`
+	}{
+		ProductID: productID,
+		TenantID:  claims.TenantID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		products
+	WHERE
+		product_id = :product_id AND
+		tenant_id = :tenant_id`
+
+	var prd Product
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &prd); err != nil {
+		if err == database.ErrNotFound {
+			return Product{}, database.ErrNotFound
+		}
+		return Product{}, fmt.Errorf("selecting product productID[%s]: %w", productID, err)
+	}
+
+	if !policy.Allowed(claims, policy.ProductRead, prd.resource()) {
+		return Product{}, database.ErrForbidden
+	}
+
+	return prd, nil
+}
+
+// QueryByUserID returns the products the user added to the tenant of the
+// claims.
+func (s Store) QueryByUserID(ctx context.Context, claims auth.Claims, userID string) ([]Product, error) {
+
+	if err := validate.CheckID(userID); err != nil {
+		return nil, database.ErrInvalidID
+	}
+
+	data := struct {
+		UserID   string `db:"user_id"`
+		TenantID string `db:"tenant_id"`
+	}{
+		UserID:   userID,
+		TenantID: claims.TenantID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		products
+	WHERE
+		user_id = :user_id AND
+		tenant_id = :tenant_id
+	ORDER BY
+		product_id`
+
+	var prds []Product
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &prds); err != nil {
+		if err == database.ErrNotFound {
+			return nil, database.ErrNotFound
+		}
+		return nil, fmt.Errorf("selecting products userID[%s]: %w", userID, err)
+	}
+
+	return prds, nil
+}
+
+// resource describes the product to the authorization policy.
+func (p Product) resource() policy.Resource {
+	return policy.Resource{
+		OwnerID:  p.UserID,
+		TenantID: p.TenantID,
+	}
+}
@@ -0,0 +1,33 @@
+package role
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrExists  = errors.New("role already exists")
+	ErrBuiltIn = errors.New("built-in roles can not be changed")
+)
+
+// Role is a named set of permissions that can be given to users.
+type Role struct {
+	Name        string         `db:"name" json:"name"`
+	Description string         `db:"description" json:"description"`
+	Permissions pq.StringArray `db:"permissions" json:"permissions"`
+	DateCreated time.Time      `db:"date_created" json:"date_created"`
+	DateUpdated time.Time      `db:"date_updated" json:"date_updated"`
+}
+
+type NewRole struct {
+	Name        string   `json:"name" validate:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRole struct {
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"`
+}
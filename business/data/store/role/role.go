@@ -0,0 +1,339 @@
+// Package role stores the roles users can be given and the permissions each
+// role grants.
+package role
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create adds a role. It must be called within a transaction so the role and
+// its permissions are stored together.
+func (s Store) Create(ctx context.Context, nr NewRole, now time.Time) (Role, error) {
+
+	if err := validate.Check(nr); err != nil {
+		return Role{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	if _, err := auth.ParseRole(nr.Name); err != nil {
+		return Role{}, fmt.Errorf("validating data: %w", &validate.FieldErrors{{Field: "name", Error: "must be upper case letters, digits or underscores"}})
+	}
+
+	perms, err := checkPermissions(nr.Permissions)
+	if err != nil {
+		return Role{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	rl := Role{
+		Name:        nr.Name,
+		Description: nr.Description,
+		Permissions: perms,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	const q = `
+	INSERT INTO roles
+		(name, description, date_created, date_updated)
+	VALUES
+		(:name, :description, :date_created, :date_updated)
+	ON CONFLICT (name) DO NOTHING
+	RETURNING
+		name`
+
+	var res struct {
+		Name string `db:"name"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, rl, &res); err != nil {
+		if err == database.ErrNotFound {
+			return Role{}, ErrExists
+		}
+		return Role{}, fmt.Errorf("inserting role: %w", err)
+	}
+
+	if err := s.setPermissions(ctx, rl.Name, perms); err != nil {
+		return Role{}, err
+	}
+
+	return rl, nil
+}
+
+// Update changes the description and, when provided, replaces the permissions
+// of the role. It must be called within a transaction.
+func (s Store) Update(ctx context.Context, name string, ur UpdateRole, now time.Time) error {
+
+	if err := validate.Check(ur); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	rl, err := s.QueryByName(ctx, name)
+	if err != nil {
+		return fmt.Errorf("updating role name[%s]: %w", name, err)
+	}
+
+	if rl.Name == auth.RoleAdmin.Name() && ur.Permissions != nil {
+		return ErrBuiltIn
+	}
+
+	if ur.Description != nil {
+		rl.Description = *ur.Description
+	}
+	rl.DateUpdated = now
+
+	const q = `
+	UPDATE
+		roles
+	SET
+		"description" = :description,
+		"date_updated" = :date_updated
+	WHERE
+		name = :name`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, rl); err != nil {
+		return fmt.Errorf("updating role name[%s]: %w", name, err)
+	}
+
+	if ur.Permissions != nil {
+		perms, err := checkPermissions(ur.Permissions)
+		if err != nil {
+			return fmt.Errorf("validating data: %w", err)
+		}
+		if err := s.setPermissions(ctx, rl.Name, perms); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete removes a role. Built-in roles can not be removed.
+func (s Store) Delete(ctx context.Context, name string) error {
+
+	if rl, err := auth.ParseRole(name); err == nil && rl.BuiltIn() {
+		return ErrBuiltIn
+	}
+
+	data := struct {
+		Name string `db:"name"`
+	}{
+		Name: name,
+	}
+
+	const q = `
+	DELETE FROM
+		roles
+	WHERE
+		name = :name
+	RETURNING
+		name`
+
+	var res struct {
+		Name string `db:"name"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		if err == database.ErrNotFound {
+			return database.ErrNotFound
+		}
+		return fmt.Errorf("deleting role name[%s]: %w", name, err)
+	}
+
+	return nil
+}
+
+// selectRoles returns every role with its permissions folded into an array.
+const selectRoles = `
+	SELECT
+		r.name, r.description, r.date_created, r.date_updated,
+		COALESCE(array_agg(p.permission ORDER BY p.permission) FILTER (WHERE p.permission IS NOT NULL), '{}') AS permissions
+	FROM
+		roles AS r
+	LEFT JOIN
+		role_permissions AS p ON p.role_name = r.name`
+
+func (s Store) Query(ctx context.Context) ([]Role, error) {
+
+	const q = selectRoles + `
+	GROUP BY
+		r.name
+	ORDER BY
+		r.name`
+
+	var rls []Role
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, struct{}{}, &rls); err != nil {
+		return nil, fmt.Errorf("selecting roles: %w", err)
+	}
+
+	return rls, nil
+}
+
+func (s Store) QueryByName(ctx context.Context, name string) (Role, error) {
+
+	data := struct {
+		Name string `db:"name"`
+	}{
+		Name: name,
+	}
+
+	const q = selectRoles + `
+	WHERE
+		r.name = :name
+	GROUP BY
+		r.name`
+
+	var rl Role
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &rl); err != nil {
+		if err == database.ErrNotFound {
+			return Role{}, database.ErrNotFound
+		}
+		return Role{}, fmt.Errorf("selecting role name[%s]: %w", name, err)
+	}
+
+	return rl, nil
+}
+
+// Check confirms every one of the roles exists.
+func (s Store) Check(ctx context.Context, roles []auth.Role) error {
+
+	names := make(pq.StringArray, len(roles))
+	for i, rl := range roles {
+		names[i] = rl.Name()
+	}
+
+	data := struct {
+		Names pq.StringArray `db:"names"`
+	}{
+		Names: names,
+	}
+
+	const q = `
+	SELECT
+		n AS name
+	FROM
+		unnest(CAST(:names AS TEXT[])) AS n
+	WHERE
+		n NOT IN (SELECT name FROM roles)`
+
+	var missing []struct {
+		Name string `db:"name"`
+	}
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &missing); err != nil {
+		return fmt.Errorf("checking roles: %w", err)
+	}
+
+	if len(missing) > 0 {
+		return &validate.FieldErrors{{Field: "roles", Error: fmt.Sprintf("role %s does not exist", missing[0].Name)}}
+	}
+
+	return nil
+}
+
+// Permissions implements auth.PermissionResolver.
+func (s Store) Permissions(ctx context.Context, roles []auth.Role) ([]auth.Permission, error) {
+
+	names := make(pq.StringArray, len(roles))
+	for i, rl := range roles {
+		names[i] = rl.Name()
+	}
+
+	data := struct {
+		Names pq.StringArray `db:"names"`
+	}{
+		Names: names,
+	}
+
+	const q = `
+	SELECT DISTINCT
+		permission
+	FROM
+		role_permissions
+	WHERE
+		role_name = ANY(CAST(:names AS TEXT[]))`
+
+	var rows []struct {
+		Permission string `db:"permission"`
+	}
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &rows); err != nil {
+		return nil, fmt.Errorf("selecting permissions: %w", err)
+	}
+
+	perms := make([]auth.Permission, 0, len(rows))
+	for _, r := range rows {
+		// Permissions the code no longer knows about grant nothing.
+		if p, err := auth.ParsePermission(r.Permission); err == nil {
+			perms = append(perms, p)
+		}
+	}
+
+	return perms, nil
+}
+
+func (s Store) setPermissions(ctx context.Context, name string, perms pq.StringArray) error {
+
+	data := struct {
+		Name        string         `db:"name"`
+		Permissions pq.StringArray `db:"permissions"`
+	}{
+		Name:        name,
+		Permissions: perms,
+	}
+
+	const del = `
+	DELETE FROM
+		role_permissions
+	WHERE
+		role_name = :name`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, del, data); err != nil {
+		return fmt.Errorf("clearing permissions role[%s]: %w", name, err)
+	}
+
+	const ins = `
+	INSERT INTO role_permissions
+		(role_name, permission)
+	SELECT
+		:name, unnest(CAST(:permissions AS TEXT[]))`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, ins, data); err != nil {
+		return fmt.Errorf("setting permissions role[%s]: %w", name, err)
+	}
+
+	return nil
+}
+
+// checkPermissions confirms every permission is known and removes duplicates.
+func checkPermissions(values []string) (pq.StringArray, error) {
+	seen := make(map[string]bool, len(values))
+	perms := make(pq.StringArray, 0, len(values))
+
+	for _, v := range values {
+		if _, err := auth.ParsePermission(v); err != nil {
+			return nil, &validate.FieldErrors{{Field: "permissions", Error: fmt.Sprintf("permission %s does not exist", v)}}
+		}
+		if !seen[v] {
+			seen[v] = true
+			perms = append(perms, v)
+		}
+	}
+
+	return perms, nil
+}
@@ -0,0 +1,103 @@
+package role_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/role"
+	"github.com/Avyukth/service3-clone/business/data/tests"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+)
+
+var dbc = tests.DBContainer{
+	Image: "postgres:latest",
+	Port:  "5432",
+	Args:  []string{"-e", "POSTGRES_PASSWORD=postgres"},
+}
+
+func TestRole(t *testing.T) {
+
+	log, db, teardown := tests.NewUnit(t, dbc)
+
+	t.Cleanup(teardown)
+
+	store := role.NewStore(log, db)
+
+	t.Log("Given the need to work with roles.")
+	{
+		testID := 0
+
+		t.Logf("\t Test %d:\tWhen handling a single role.", testID)
+		{
+			ctx := context.Background()
+			now := time.Now().UTC()
+
+			nr := role.NewRole{
+				Name:        "AUDITOR",
+				Description: "Reads the audit log",
+				Permissions: []string{auth.PermAuditRead.Name()},
+			}
+
+			if _, err := store.Create(ctx, nr, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a role : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a role.", tests.Success, testID)
+
+			if _, err := store.Create(ctx, nr, now); !errors.Is(err, role.ErrExists) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to create the role twice : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to create the role twice.", tests.Success, testID)
+
+			perms, err := store.Permissions(ctx, []auth.Role{auth.RoleUser, auth.MustParseRole("AUDITOR")})
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to resolve permissions : %s.", tests.Failed, testID, err)
+			}
+			if !contains(perms, auth.PermAuditRead) || !contains(perms, auth.PermSalesWrite) || contains(perms, auth.PermUsersWrite) {
+				t.Fatalf("\t%s\tTest %d:\tShould get the union of the permissions of the roles : %v.", tests.Failed, testID, perms)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the union of the permissions of the roles.", tests.Success, testID)
+
+			ur := role.UpdateRole{
+				Permissions: []string{auth.PermAuditRead.Name(), auth.PermWebhooksRead.Name()},
+			}
+			if err := store.Update(ctx, "AUDITOR", ur, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to update the role : %s.", tests.Failed, testID, err)
+			}
+
+			saved, err := store.QueryByName(ctx, "AUDITOR")
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve the role : %s.", tests.Failed, testID, err)
+			}
+			if len(saved.Permissions) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould see the updated permissions : %v.", tests.Failed, testID, saved.Permissions)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to update the role.", tests.Success, testID)
+
+			if err := store.Check(ctx, []auth.Role{auth.MustParseRole("AUDITOR"), auth.MustParseRole("MISSING")}); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould reject roles that do not exist.", tests.Failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould reject roles that do not exist.", tests.Success, testID)
+
+			if err := store.Delete(ctx, auth.RoleAdmin.Name()); !errors.Is(err, role.ErrBuiltIn) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to delete a built-in role : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to delete a built-in role.", tests.Success, testID)
+
+			if err := store.Delete(ctx, "AUDITOR"); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete the role : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to delete the role.", tests.Success, testID)
+		}
+	}
+}
+
+func contains(perms []auth.Permission, perm auth.Permission) bool {
+	for _, p := range perms {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package sale
+
+import (
+	"fmt"
+	"time"
+)
+
+type Sale struct {
+	ID          string    `db:"sale_id" json:"id"`
+	TenantID    string    `db:"tenant_id" json:"tenant_id"`
+	UserID      string    `db:"user_id" json:"user_id"`
+	ProductID   string    `db:"product_id" json:"product_id"`
+	Quantity    int       `db:"quantity" json:"quantity"`
+	Paid        int       `db:"paid" json:"paid"`
+	DateCreated time.Time `db:"date_created" json:"date_created"`
+	DateUpdated time.Time `db:"date_updated" json:"date_updated"`
+}
+
+type NewSale struct {
+	ProductID string `json:"product_id" validate:"required,uuid"`
+	Quantity  int    `json:"quantity" validate:"required,gte=1"`
+}
+
+// OversellError is returned when a sale asks for more units of a product
+// than are currently in stock.
+type OversellError struct {
+	ProductID string
+	Requested int
+	Available int
+}
+
+func (e *OversellError) Error() string {
+	return fmt.Sprintf("insufficient stock for product[%s]: requested %d, available %d", e.ProductID, e.Requested, e.Available)
+}
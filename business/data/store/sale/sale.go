@@ -0,0 +1,241 @@
+package sale
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/policy"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create records a sale for the authenticated user. The product row is locked,
+// its stock checked and decremented and the sale inserted inside a single
+// transaction so concurrent buyers can never oversell a product. Only
+// products of the tenant of the claims can be bought.
+func (s Store) Create(ctx context.Context, claims auth.Claims, ns NewSale, now time.Time) (Sale, error) {
+
+	if err := validate.Check(ns); err != nil {
+		return Sale{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	var sl Sale
+	f := func(ctx context.Context) error {
+		data := struct {
+			ProductID   string    `db:"product_id"`
+			TenantID    string    `db:"tenant_id"`
+			Quantity    int       `db:"quantity"`
+			DateUpdated time.Time `db:"date_updated"`
+		}{
+			ProductID:   ns.ProductID,
+			TenantID:    claims.TenantID,
+			Quantity:    ns.Quantity,
+			DateUpdated: now,
+		}
+
+		const qStock = `
+		SELECT
+			cost, quantity
+		FROM
+			products
+		WHERE
+			product_id = :product_id AND
+			tenant_id = :tenant_id
+		FOR UPDATE`
+
+		var stock struct {
+			Cost     int `db:"cost"`
+			Quantity int `db:"quantity"`
+		}
+		if err := database.NamedQueryStruct(ctx, s.log, s.db, qStock, data, &stock); err != nil {
+			if err == database.ErrNotFound {
+				return database.ErrNotFound
+			}
+			return fmt.Errorf("selecting product productID[%s]: %w", ns.ProductID, err)
+		}
+
+		if stock.Quantity < ns.Quantity {
+			return &OversellError{
+				ProductID: ns.ProductID,
+				Requested: ns.Quantity,
+				Available: stock.Quantity,
+			}
+		}
+
+		const qDecrement = `
+		UPDATE
+			products
+		SET
+			"quantity" = "quantity" - :quantity,
+			"date_updated" = :date_updated
+		WHERE
+			product_id = :product_id AND
+			tenant_id = :tenant_id`
+
+		if err := database.NamedExecContext(ctx, s.log, s.db, qDecrement, data); err != nil {
+			return fmt.Errorf("decrementing stock productID[%s]: %w", ns.ProductID, err)
+		}
+
+		sl = Sale{
+			ID:          validate.GenerateID(),
+			TenantID:    claims.TenantID,
+			UserID:      claims.Subject,
+			ProductID:   ns.ProductID,
+			Quantity:    ns.Quantity,
+			Paid:        stock.Cost * ns.Quantity,
+			DateCreated: now,
+			DateUpdated: now,
+		}
+
+		const qInsert = `
+		INSERT INTO sales
+			(sale_id, tenant_id, user_id, product_id, quantity, paid, date_created, date_updated)
+		VALUES
+			(:sale_id, :tenant_id, :user_id, :product_id, :quantity, :paid, :date_created, :date_updated)`
+
+		if err := database.NamedExecContext(ctx, s.log, s.db, qInsert, sl); err != nil {
+			return fmt.Errorf("inserting sale: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := database.WithinTran(ctx, s.db, f); err != nil {
+		return Sale{}, err
+	}
+
+	return sl, nil
+}
+
+// Query returns a page of the sales of the tenant of the claims.
+func (s Store) Query(ctx context.Context, claims auth.Claims, pageNumber int, rowsPerPage int) ([]Sale, error) {
+
+	if !policy.Allowed(claims, policy.SaleQuery, policy.Resource{TenantID: claims.TenantID}) {
+		return nil, database.ErrForbidden
+	}
+
+	data := struct {
+		TenantID    string `db:"tenant_id"`
+		Offset      int    `db:"offset"`
+		RowsPerPage int    `db:"rows_per_page"`
+	}{
+		TenantID:    claims.TenantID,
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sales
+	WHERE
+		tenant_id = :tenant_id
+	ORDER BY
+		date_created DESC, sale_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var sales []Sale
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &sales); err != nil {
+		if err == database.ErrNotFound {
+			return nil, database.ErrNotFound
+		}
+		return nil, fmt.Errorf("selecting sales: %w", err)
+	}
+
+	return sales, nil
+}
+
+func (s Store) QueryByID(ctx context.Context, claims auth.Claims, saleID string) (Sale, error) {
+
+	if err := validate.CheckID(saleID); err != nil {
+		return Sale{}, database.ErrInvalidID
+	}
+
+	data := struct {
+		SaleID   string `db:"sale_id"`
+		TenantID string `db:"tenant_id"`
+	}{
+		SaleID:   saleID,
+		TenantID: claims.TenantID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sales
+	WHERE
+		sale_id = :sale_id AND
+		tenant_id = :tenant_id`
+
+	var sl Sale
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &sl); err != nil {
+		if err == database.ErrNotFound {
+			return Sale{}, database.ErrNotFound
+		}
+		return Sale{}, fmt.Errorf("selecting sale saleID[%s]: %w", saleID, err)
+	}
+
+	if !policy.Allowed(claims, policy.SaleRead, policy.Resource{OwnerID: sl.UserID, TenantID: sl.TenantID}) {
+		return Sale{}, database.ErrForbidden
+	}
+
+	return sl, nil
+}
+
+func (s Store) QueryByUserID(ctx context.Context, claims auth.Claims, userID string) ([]Sale, error) {
+
+	if err := validate.CheckID(userID); err != nil {
+		return nil, database.ErrInvalidID
+	}
+
+	if !policy.Allowed(claims, policy.SaleQueryByUser, policy.Resource{OwnerID: userID, TenantID: claims.TenantID}) {
+		return nil, database.ErrForbidden
+	}
+
+	data := struct {
+		UserID   string `db:"user_id"`
+		TenantID string `db:"tenant_id"`
+	}{
+		UserID:   userID,
+		TenantID: claims.TenantID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sales
+	WHERE
+		user_id = :user_id AND
+		tenant_id = :tenant_id
+	ORDER BY
+		date_created DESC, sale_id`
+
+	var sales []Sale
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &sales); err != nil {
+		if err == database.ErrNotFound {
+			return nil, database.ErrNotFound
+		}
+		return nil, fmt.Errorf("selecting sales userID[%s]: %w", userID, err)
+	}
+
+	return sales, nil
+}
@@ -0,0 +1,99 @@
+package sale_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/product"
+	"github.com/Avyukth/service3-clone/business/data/store/sale"
+	"github.com/Avyukth/service3-clone/business/data/store/tenant"
+	"github.com/Avyukth/service3-clone/business/data/tests"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var dbc = tests.DBContainer{
+	Image: "postgres:latest",
+	Port:  "5432",
+	Args:  []string{"-e", "POSTGRES_PASSWORD=postgres"},
+}
+
+func TestSale(t *testing.T) {
+
+	log, db, teardown := tests.NewUnit(t, dbc)
+
+	t.Cleanup(teardown)
+
+	products := product.NewStore(log, db)
+	store := sale.NewStore(log, db)
+
+	t.Log("Given the need to work with the sale records.")
+	{
+		testID := 0
+
+		t.Logf("\t Test %d:\tWhen selling a product.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+			claims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+					IssuedAt:  jwt.NewNumericDate(time.Now()),
+					NotBefore: jwt.NewNumericDate(time.Now()),
+					Issuer:    "service project",
+					Subject:   "b2e48272-2222-4106-888f-9325122678b2",
+					ID:        uuid.New().String(),
+				},
+				Roles:    []auth.Role{auth.RoleUser},
+				TenantID: tenant.DefaultID,
+			}
+			claims = tests.Grant(t, log, db, claims)
+
+			prd, err := products.Create(ctx, claims, product.NewProduct{Name: "Puzzle", Cost: 25, Quantity: 3}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a product : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a product.", tests.Success, testID)
+
+			sl, err := store.Create(ctx, claims, sale.NewSale{ProductID: prd.ID, Quantity: 2}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a sale : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a sale.", tests.Success, testID)
+
+			if sl.Paid != 50 {
+				t.Fatalf("\t%s\tTest %d:\tShould record the amount paid : got %d, exp %d.", tests.Failed, testID, sl.Paid, 50)
+			}
+			t.Logf("\t%s\tTest %d:\tShould record the amount paid.", tests.Success, testID)
+
+			saved, err := products.QueryByID(ctx, claims, prd.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve product : %s.", tests.Failed, testID, err)
+			}
+			if saved.Quantity != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould decrement the product stock : got %d, exp %d.", tests.Failed, testID, saved.Quantity, 1)
+			}
+			t.Logf("\t%s\tTest %d:\tShould decrement the product stock.", tests.Success, testID)
+
+			_, err = store.Create(ctx, claims, sale.NewSale{ProductID: prd.ID, Quantity: 2}, now)
+			var oe *sale.OversellError
+			if !errors.As(err, &oe) {
+				t.Fatalf("\t%s\tTest %d:\tShould reject an oversell : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould reject an oversell.", tests.Success, testID)
+
+			saved, err = products.QueryByID(ctx, claims, prd.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve product : %s.", tests.Failed, testID, err)
+			}
+			if saved.Quantity != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould leave the stock untouched after an oversell : got %d, exp %d.", tests.Failed, testID, saved.Quantity, 1)
+			}
+			t.Logf("\t%s\tTest %d:\tShould leave the stock untouched after an oversell.", tests.Success, testID)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package tenant
+
+import (
+	"errors"
+	"time"
+)
+
+// DefaultID is the tenant every row created before tenants existed was moved
+// to. It can not be deleted or deactivated.
+const DefaultID = "0f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+
+var (
+	ErrExists  = errors.New("tenant already exists")
+	ErrInUse   = errors.New("tenant still owns data")
+	ErrDefault = errors.New("the default tenant can not be deleted or deactivated")
+)
+
+// Tenant is a storefront. Users, products and sales belong to exactly one
+// tenant and are only visible within it.
+type Tenant struct {
+	ID          string    `db:"tenant_id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	Active      bool      `db:"active" json:"active"`
+	DateCreated time.Time `db:"date_created" json:"date_created"`
+	DateUpdated time.Time `db:"date_updated" json:"date_updated"`
+}
+
+type NewTenant struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// UpdateTenant renames a tenant or suspends it. Users of an inactive tenant
+// can not log in or refresh their tokens.
+type UpdateTenant struct {
+	Name   *string `json:"name" validate:"omitempty,min=1"`
+	Active *bool   `json:"active"`
+}
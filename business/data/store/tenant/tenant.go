@@ -0,0 +1,219 @@
+// Package tenant stores the tenants the data of the service is partitioned
+// by.
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		db:  db,
+	}
+}
+
+func (s Store) Create(ctx context.Context, nt NewTenant, now time.Time) (Tenant, error) {
+
+	if err := validate.Check(nt); err != nil {
+		return Tenant{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	tnt := Tenant{
+		ID:          validate.GenerateID(),
+		Name:        nt.Name,
+		Active:      true,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	const q = `
+	INSERT INTO tenants
+		(tenant_id, name, active, date_created, date_updated)
+	VALUES
+		(:tenant_id, :name, :active, :date_created, :date_updated)
+	ON CONFLICT (name) DO NOTHING
+	RETURNING
+		tenant_id`
+
+	var res struct {
+		ID string `db:"tenant_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, tnt, &res); err != nil {
+		if err == database.ErrNotFound {
+			return Tenant{}, ErrExists
+		}
+		return Tenant{}, fmt.Errorf("inserting tenant: %w", err)
+	}
+
+	return tnt, nil
+}
+
+func (s Store) Update(ctx context.Context, tenantID string, ut UpdateTenant, now time.Time) error {
+
+	if err := validate.CheckID(tenantID); err != nil {
+		return database.ErrInvalidID
+	}
+
+	if err := validate.Check(ut); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	tnt, err := s.QueryByID(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("updating tenant tenantID[%s]: %w", tenantID, err)
+	}
+
+	if ut.Name != nil {
+		tnt.Name = *ut.Name
+	}
+	if ut.Active != nil {
+		if tenantID == DefaultID && !*ut.Active {
+			return ErrDefault
+		}
+		tnt.Active = *ut.Active
+	}
+	tnt.DateUpdated = now
+
+	// The name is only taken when no other tenant has it, the unique
+	// constraint catches the rare concurrent rename.
+	const q = `
+	UPDATE
+		tenants
+	SET
+		"name" = :name,
+		"active" = :active,
+		"date_updated" = :date_updated
+	WHERE
+		tenant_id = :tenant_id AND
+		NOT EXISTS (SELECT 1 FROM tenants WHERE name = :name AND tenant_id <> :tenant_id)
+	RETURNING
+		tenant_id`
+
+	var res struct {
+		ID string `db:"tenant_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, tnt, &res); err != nil {
+		if err == database.ErrNotFound {
+			return ErrExists
+		}
+		return fmt.Errorf("updating tenant tenantID[%s]: %w", tenantID, err)
+	}
+
+	return nil
+}
+
+// Delete removes a tenant that no longer owns any users, products or sales.
+func (s Store) Delete(ctx context.Context, tenantID string) error {
+
+	if err := validate.CheckID(tenantID); err != nil {
+		return database.ErrInvalidID
+	}
+
+	if tenantID == DefaultID {
+		return ErrDefault
+	}
+
+	if _, err := s.QueryByID(ctx, tenantID); err != nil {
+		return fmt.Errorf("deleting tenant tenantID[%s]: %w", tenantID, err)
+	}
+
+	data := struct {
+		ID string `db:"tenant_id"`
+	}{
+		ID: tenantID,
+	}
+
+	const q = `
+	DELETE FROM
+		tenants
+	WHERE
+		tenant_id = :tenant_id AND
+		NOT EXISTS (SELECT 1 FROM users WHERE tenant_id = :tenant_id) AND
+		NOT EXISTS (SELECT 1 FROM products WHERE tenant_id = :tenant_id) AND
+		NOT EXISTS (SELECT 1 FROM sales WHERE tenant_id = :tenant_id)
+	RETURNING
+		tenant_id`
+
+	var res struct {
+		ID string `db:"tenant_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		if err == database.ErrNotFound {
+			return ErrInUse
+		}
+		return fmt.Errorf("deleting tenant tenantID[%s]: %w", tenantID, err)
+	}
+
+	return nil
+}
+
+func (s Store) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]Tenant, error) {
+
+	data := struct {
+		Offset      int `db:"offset"`
+		RowsPerPage int `db:"rows_per_page"`
+	}{
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		tenants
+	ORDER BY
+		name, tenant_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var tnts []Tenant
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &tnts); err != nil {
+		return nil, fmt.Errorf("selecting tenants: %w", err)
+	}
+
+	return tnts, nil
+}
+
+func (s Store) QueryByID(ctx context.Context, tenantID string) (Tenant, error) {
+
+	if err := validate.CheckID(tenantID); err != nil {
+		return Tenant{}, database.ErrInvalidID
+	}
+
+	data := struct {
+		ID string `db:"tenant_id"`
+	}{
+		ID: tenantID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		tenants
+	WHERE
+		tenant_id = :tenant_id`
+
+	var tnt Tenant
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &tnt); err != nil {
+		if err == database.ErrNotFound {
+			return Tenant{}, database.ErrNotFound
+		}
+		return Tenant{}, fmt.Errorf("selecting tenant tenantID[%s]: %w", tenantID, err)
+	}
+
+	return tnt, nil
+}
@@ -0,0 +1,96 @@
+package tenant_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/tenant"
+	"github.com/Avyukth/service3-clone/business/data/store/user"
+	"github.com/Avyukth/service3-clone/business/data/tests"
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+)
+
+var dbc = tests.DBContainer{
+	Image: "postgres:latest",
+	Port:  "5432",
+	Args:  []string{"-e", "POSTGRES_PASSWORD=postgres"},
+}
+
+func TestTenant(t *testing.T) {
+
+	log, db, teardown := tests.NewUnit(t, dbc)
+
+	t.Cleanup(teardown)
+
+	store := tenant.NewStore(log, db)
+	users := user.NewStore(log, db)
+
+	t.Log("Given the need to work with tenants.")
+	{
+		testID := 0
+
+		t.Logf("\t Test %d:\tWhen handling a single tenant.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+			tnt, err := store.Create(ctx, tenant.NewTenant{Name: "Acme"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a tenant : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a tenant.", tests.Success, testID)
+
+			if _, err := store.Create(ctx, tenant.NewTenant{Name: "Acme"}, now); !errors.Is(err, tenant.ErrExists) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to create the tenant twice : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to create the tenant twice.", tests.Success, testID)
+
+			nu := user.NewUser{
+				Name:            "Wile E. Coyote",
+				Email:           "wile@acme.com",
+				Roles:           []auth.Role{auth.RoleUser},
+				Password:        "gophers",
+				PasswordConfirm: "gophers",
+			}
+			usr, err := users.Create(ctx, tnt.ID, nu, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a user in the tenant : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a user in the tenant.", tests.Success, testID)
+
+			other := auth.Claims{Roles: []auth.Role{auth.RoleAdmin}, TenantID: tenant.DefaultID}
+			if _, err := users.QueryByID(ctx, other, usr.ID); !errors.Is(err, database.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould not see the user from another tenant : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not see the user from another tenant.", tests.Success, testID)
+
+			if err := store.Delete(ctx, tnt.ID); !errors.Is(err, tenant.ErrInUse) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to delete a tenant owning users : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to delete a tenant owning users.", tests.Success, testID)
+
+			if err := store.Update(ctx, tnt.ID, tenant.UpdateTenant{Active: tests.BoolPointer(false)}, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to suspend the tenant : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to suspend the tenant.", tests.Success, testID)
+
+			if _, err := users.Authenticate(ctx, now, nu.Email, nu.Password); !errors.Is(err, database.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to log in to a suspended tenant : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to log in to a suspended tenant.", tests.Success, testID)
+
+			if err := store.Update(ctx, tenant.DefaultID, tenant.UpdateTenant{Active: tests.BoolPointer(false)}, now); !errors.Is(err, tenant.ErrDefault) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to suspend the default tenant : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to suspend the default tenant.", tests.Success, testID)
+
+			if err := store.Delete(ctx, tenant.DefaultID); !errors.Is(err, tenant.ErrDefault) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to delete the default tenant : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to delete the default tenant.", tests.Success, testID)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package token
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrExpired = errors.New("refresh token expired")
+	ErrReused  = errors.New("refresh token reuse detected")
+
+	ErrResetExpired = errors.New("password reset token expired")
+	ErrResetUsed    = errors.New("password reset token already used")
+)
+
+// RefreshToken is a single link in a chain of rotated refresh tokens. Every
+// token issued from the same login shares a FamilyID. Only the hash of the
+// token handed to the client is stored.
+type RefreshToken struct {
+	ID          string     `db:"token_id"`
+	UserID      string     `db:"user_id"`
+	FamilyID    string     `db:"family_id"`
+	TokenHash   string     `db:"token_hash"`
+	ReplacedBy  *string    `db:"replaced_by"`
+	DateExpires time.Time  `db:"date_expires"`
+	DateRevoked *time.Time `db:"date_revoked"`
+	DateCreated time.Time  `db:"date_created"`
+}
+
+// PasswordReset is a single-use token that lets a user set a new password.
+// Only the hash of the token sent to the user is stored.
+type PasswordReset struct {
+	ID          string     `db:"reset_id"`
+	UserID      string     `db:"user_id"`
+	TokenHash   string     `db:"token_hash"`
+	DateExpires time.Time  `db:"date_expires"`
+	DateUsed    *time.Time `db:"date_used"`
+	DateCreated time.Time  `db:"date_created"`
+}
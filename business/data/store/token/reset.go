@@ -0,0 +1,140 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+)
+
+// CreateReset issues a password reset token for the user and returns the raw
+// token to send to them.
+func (s Store) CreateReset(ctx context.Context, userID string, now time.Time, ttl time.Duration) (string, error) {
+
+	if err := validate.CheckID(userID); err != nil {
+		return "", database.ErrInvalidID
+	}
+
+	raw, err := generate()
+	if err != nil {
+		return "", fmt.Errorf("generating reset token: %w", err)
+	}
+
+	pr := PasswordReset{
+		ID:          validate.GenerateID(),
+		UserID:      userID,
+		TokenHash:   hash(raw),
+		DateExpires: now.Add(ttl),
+		DateCreated: now,
+	}
+
+	const q = `
+	INSERT INTO password_resets
+		(reset_id, user_id, token_hash, date_expires, date_created)
+	VALUES
+		(:reset_id, :user_id, :token_hash, :date_expires, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, pr); err != nil {
+		return "", fmt.Errorf("inserting reset token: %w", err)
+	}
+
+	return raw, nil
+}
+
+// ConsumeReset marks the password reset token as used and returns the user it
+// was issued to. A token can only be consumed once.
+func (s Store) ConsumeReset(ctx context.Context, raw string, now time.Time) (string, error) {
+
+	var userID string
+
+	f := func(ctx context.Context) error {
+		data := struct {
+			TokenHash string `db:"token_hash"`
+		}{
+			TokenHash: hash(raw),
+		}
+
+		const q = `
+		SELECT
+			*
+		FROM
+			password_resets
+		WHERE
+			token_hash = :token_hash
+		FOR UPDATE`
+
+		var pr PasswordReset
+		if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &pr); err != nil {
+			if err == database.ErrNotFound {
+				return database.ErrAuthenticationFailure
+			}
+			return fmt.Errorf("selecting reset token: %w", err)
+		}
+
+		if pr.DateUsed != nil {
+			return ErrResetUsed
+		}
+		if !now.Before(pr.DateExpires) {
+			return ErrResetExpired
+		}
+
+		used := struct {
+			ResetID  string    `db:"reset_id"`
+			DateUsed time.Time `db:"date_used"`
+		}{
+			ResetID:  pr.ID,
+			DateUsed: now,
+		}
+
+		const uq = `
+		UPDATE
+			password_resets
+		SET
+			"date_used" = :date_used
+		WHERE
+			reset_id = :reset_id`
+
+		if err := database.NamedExecContext(ctx, s.log, s.db, uq, used); err != nil {
+			return fmt.Errorf("using reset token resetID[%s]: %w", pr.ID, err)
+		}
+
+		userID = pr.UserID
+		return nil
+	}
+
+	if err := database.WithinTran(ctx, s.db, f); err != nil {
+		return "", err
+	}
+
+	return userID, nil
+}
+
+// RevokeUser revokes every refresh token of the user, ending all of their
+// login sessions.
+func (s Store) RevokeUser(ctx context.Context, userID string, now time.Time) error {
+
+	data := struct {
+		UserID      string    `db:"user_id"`
+		DateRevoked time.Time `db:"date_revoked"`
+	}{
+		UserID:      userID,
+		DateRevoked: now,
+	}
+
+	const q = `
+	UPDATE
+		refresh_tokens
+	SET
+		"date_revoked" = :date_revoked
+	WHERE
+		user_id = :user_id AND
+		date_revoked IS NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("revoking refresh tokens userID[%s]: %w", userID, err)
+	}
+
+	return nil
+}
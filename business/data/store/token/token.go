@@ -0,0 +1,327 @@
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/policy"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create issues a refresh token starting a new family for the user and
+// returns the raw token to hand to the client.
+func (s Store) Create(ctx context.Context, userID string, now time.Time, ttl time.Duration) (string, error) {
+
+	if err := validate.CheckID(userID); err != nil {
+		return "", database.ErrInvalidID
+	}
+
+	raw, _, err := s.insert(ctx, userID, validate.GenerateID(), now, ttl)
+	if err != nil {
+		return "", err
+	}
+
+	return raw, nil
+}
+
+// Rotate exchanges a refresh token for a new one in the same family and
+// returns the owning user. Presenting a token that was already rotated or
+// revoked revokes the whole family and returns ErrReused.
+func (s Store) Rotate(ctx context.Context, raw string, now time.Time, ttl time.Duration) (string, string, error) {
+
+	var userID, next string
+	var reused bool
+
+	f := func(ctx context.Context) error {
+		rt, err := s.queryByHash(ctx, raw)
+		if err != nil {
+			return err
+		}
+
+		if rt.DateRevoked != nil {
+			reused = true
+			return s.revokeFamily(ctx, rt.FamilyID, now)
+		}
+
+		if !now.Before(rt.DateExpires) {
+			return ErrExpired
+		}
+
+		var nextID string
+		next, nextID, err = s.insert(ctx, rt.UserID, rt.FamilyID, now, ttl)
+		if err != nil {
+			return err
+		}
+
+		data := struct {
+			TokenID     string    `db:"token_id"`
+			ReplacedBy  string    `db:"replaced_by"`
+			DateRevoked time.Time `db:"date_revoked"`
+		}{
+			TokenID:     rt.ID,
+			ReplacedBy:  nextID,
+			DateRevoked: now,
+		}
+
+		const q = `
+		UPDATE
+			refresh_tokens
+		SET
+			"replaced_by" = :replaced_by,
+			"date_revoked" = :date_revoked
+		WHERE
+			token_id = :token_id`
+
+		if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+			return fmt.Errorf("rotating refresh token tokenID[%s]: %w", rt.ID, err)
+		}
+
+		userID = rt.UserID
+		return nil
+	}
+
+	// The family revocation on reuse must be committed, so the reuse error
+	// is only reported once the transaction has finished.
+	if err := database.WithinTran(ctx, s.db, f); err != nil {
+		return "", "", err
+	}
+	if reused {
+		return "", "", ErrReused
+	}
+
+	return userID, next, nil
+}
+
+// Revoke revokes every refresh token in the family of the provided token. Only
+// the owner of the token or an admin can do this.
+func (s Store) Revoke(ctx context.Context, claims auth.Claims, raw string, now time.Time) error {
+
+	rt, err := s.queryByHash(ctx, raw)
+	if err != nil {
+		return err
+	}
+
+	// The tenant of the token is the tenant of its owner.
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: rt.UserID,
+	}
+
+	const q = `
+	SELECT
+		tenant_id
+	FROM
+		users
+	WHERE
+		user_id = :user_id`
+
+	var owner struct {
+		TenantID string `db:"tenant_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &owner); err != nil {
+		if err == database.ErrNotFound {
+			return database.ErrForbidden
+		}
+		return fmt.Errorf("selecting owner of refresh token: %w", err)
+	}
+
+	if !policy.Allowed(claims, policy.TokenRevoke, policy.Resource{OwnerID: rt.UserID, TenantID: owner.TenantID}) {
+		return database.ErrForbidden
+	}
+
+	return s.revokeFamily(ctx, rt.FamilyID, now)
+}
+
+// RevokeAccess adds the id (jti) of an access token to the deny list until the
+// token would have expired on its own.
+func (s Store) RevokeAccess(ctx context.Context, jti string, expires time.Time) error {
+
+	data := struct {
+		JTI         string    `db:"jti"`
+		DateExpires time.Time `db:"date_expires"`
+	}{
+		JTI:         jti,
+		DateExpires: expires,
+	}
+
+	const q = `
+	INSERT INTO revoked_tokens
+		(jti, date_expires)
+	VALUES
+		(:jti, :date_expires)
+	ON CONFLICT DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("revoking access token jti[%s]: %w", jti, err)
+	}
+
+	return nil
+}
+
+// PruneRevoked removes the deny list entries of access tokens that have
+// expired on their own.
+func (s Store) PruneRevoked(ctx context.Context, now time.Time) error {
+
+	data := struct {
+		Now time.Time `db:"now"`
+	}{
+		Now: now,
+	}
+
+	const q = `
+	DELETE FROM
+		revoked_tokens
+	WHERE
+		date_expires < :now`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("pruning revoked tokens: %w", err)
+	}
+
+	return nil
+}
+
+// IsRevoked reports whether the access token id (jti) is on the deny list.
+func (s Store) IsRevoked(ctx context.Context, jti string) (bool, error) {
+
+	data := struct {
+		JTI string `db:"jti"`
+	}{
+		JTI: jti,
+	}
+
+	const q = `
+	SELECT
+		count(1) AS count
+	FROM
+		revoked_tokens
+	WHERE
+		jti = :jti`
+
+	var res struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		return false, fmt.Errorf("checking revoked token jti[%s]: %w", jti, err)
+	}
+
+	return res.Count > 0, nil
+}
+
+func (s Store) insert(ctx context.Context, userID string, familyID string, now time.Time, ttl time.Duration) (string, string, error) {
+
+	raw, err := generate()
+	if err != nil {
+		return "", "", fmt.Errorf("generating refresh token: %w", err)
+	}
+
+	rt := RefreshToken{
+		ID:          validate.GenerateID(),
+		UserID:      userID,
+		FamilyID:    familyID,
+		TokenHash:   hash(raw),
+		DateExpires: now.Add(ttl),
+		DateCreated: now,
+	}
+
+	const q = `
+	INSERT INTO refresh_tokens
+		(token_id, user_id, family_id, token_hash, date_expires, date_created)
+	VALUES
+		(:token_id, :user_id, :family_id, :token_hash, :date_expires, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, rt); err != nil {
+		return "", "", fmt.Errorf("inserting refresh token: %w", err)
+	}
+
+	return raw, rt.ID, nil
+}
+
+func (s Store) queryByHash(ctx context.Context, raw string) (RefreshToken, error) {
+
+	data := struct {
+		TokenHash string `db:"token_hash"`
+	}{
+		TokenHash: hash(raw),
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		refresh_tokens
+	WHERE
+		token_hash = :token_hash
+	FOR UPDATE`
+
+	var rt RefreshToken
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &rt); err != nil {
+		if err == database.ErrNotFound {
+			return RefreshToken{}, database.ErrAuthenticationFailure
+		}
+		return RefreshToken{}, fmt.Errorf("selecting refresh token: %w", err)
+	}
+
+	return rt, nil
+}
+
+func (s Store) revokeFamily(ctx context.Context, familyID string, now time.Time) error {
+
+	data := struct {
+		FamilyID    string    `db:"family_id"`
+		DateRevoked time.Time `db:"date_revoked"`
+	}{
+		FamilyID:    familyID,
+		DateRevoked: now,
+	}
+
+	const q = `
+	UPDATE
+		refresh_tokens
+	SET
+		"date_revoked" = :date_revoked
+	WHERE
+		family_id = :family_id AND
+		date_revoked IS NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("revoking refresh token family[%s]: %w", familyID, err)
+	}
+
+	return nil
+}
+
+func generate() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
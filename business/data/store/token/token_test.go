@@ -0,0 +1,139 @@
+package token_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/token"
+	"github.com/Avyukth/service3-clone/business/data/tests"
+)
+
+var dbc = tests.DBContainer{
+	Image: "postgres:latest",
+	Port:  "5432",
+	Args:  []string{"-e", "POSTGRES_PASSWORD=postgres"},
+}
+
+func TestRefreshToken(t *testing.T) {
+
+	log, db, teardown := tests.NewUnit(t, dbc)
+
+	t.Cleanup(teardown)
+
+	store := token.NewStore(log, db)
+
+	t.Log("Given the need to rotate refresh tokens.")
+	{
+		testID := 0
+
+		t.Logf("\t Test %d:\tWhen handling a single login session.", testID)
+		{
+			ctx := context.Background()
+			now := time.Now().UTC()
+			const userID = "b2e48272-2222-4106-888f-9325122678b2"
+
+			first, err := store.Create(ctx, userID, now, time.Hour)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a refresh token : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a refresh token.", tests.Success, testID)
+
+			gotID, second, err := store.Rotate(ctx, first, now, time.Hour)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to rotate the refresh token : %s.", tests.Failed, testID, err)
+			}
+			if gotID != userID {
+				t.Fatalf("\t%s\tTest %d:\tShould get back the owner of the token : got %s, exp %s.", tests.Failed, testID, gotID, userID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to rotate the refresh token.", tests.Success, testID)
+
+			if _, _, err := store.Rotate(ctx, first, now, time.Hour); !errors.Is(err, token.ErrReused) {
+				t.Fatalf("\t%s\tTest %d:\tShould detect reuse of a rotated token : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould detect reuse of a rotated token.", tests.Success, testID)
+
+			if _, _, err := store.Rotate(ctx, second, now, time.Hour); !errors.Is(err, token.ErrReused) {
+				t.Fatalf("\t%s\tTest %d:\tShould revoke the whole family after reuse : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould revoke the whole family after reuse.", tests.Success, testID)
+
+			expired, err := store.Create(ctx, userID, now.Add(-2*time.Hour), time.Hour)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a refresh token : %s.", tests.Failed, testID, err)
+			}
+			if _, _, err := store.Rotate(ctx, expired, now, time.Hour); !errors.Is(err, token.ErrExpired) {
+				t.Fatalf("\t%s\tTest %d:\tShould reject an expired token : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould reject an expired token.", tests.Success, testID)
+		}
+	}
+
+	t.Log("Given the need to revoke access tokens.")
+	{
+		testID := 1
+
+		t.Logf("\t Test %d:\tWhen revoking a token id.", testID)
+		{
+			ctx := context.Background()
+			const jti = "8f4a4c5e-2f6f-4c64-9b6e-5a3c7d2f1e90"
+
+			if err := store.RevokeAccess(ctx, jti, time.Now().Add(time.Hour)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to revoke the token : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to revoke the token.", tests.Success, testID)
+
+			revoked, err := store.IsRevoked(ctx, jti)
+			if err != nil || !revoked {
+				t.Fatalf("\t%s\tTest %d:\tShould report the token as revoked : %v, %v.", tests.Failed, testID, revoked, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould report the token as revoked.", tests.Success, testID)
+
+			const expired = "0c9d8e7f-6a5b-4c3d-8e2f-1a0b9c8d7e6f"
+			if err := store.RevokeAccess(ctx, expired, time.Now().Add(-time.Hour)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to revoke an expired token : %s.", tests.Failed, testID, err)
+			}
+			if err := store.PruneRevoked(ctx, time.Now()); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to prune the deny list : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to prune the deny list.", tests.Success, testID)
+
+			if revoked, err := store.IsRevoked(ctx, expired); err != nil || revoked {
+				t.Fatalf("\t%s\tTest %d:\tShould drop the expired token from the deny list : %v, %v.", tests.Failed, testID, revoked, err)
+			}
+			if revoked, err := store.IsRevoked(ctx, jti); err != nil || !revoked {
+				t.Fatalf("\t%s\tTest %d:\tShould keep the live token on the deny list : %v, %v.", tests.Failed, testID, revoked, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould only drop expired tokens from the deny list.", tests.Success, testID)
+		}
+	}
+	t.Log("Given the need to reset passwords.")
+	{
+		testID := 2
+
+		t.Logf("\t Test %d:\tWhen using a reset token.", testID)
+		{
+			ctx := context.Background()
+			now := time.Now().UTC()
+			const userID = "c3d59378-3333-4206-777f-9325122678c3"
+
+			raw, err := store.CreateReset(ctx, userID, now, time.Hour)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a reset token : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a reset token.", tests.Success, testID)
+
+			gotID, err := store.ConsumeReset(ctx, raw, now)
+			if err != nil || gotID != userID {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to use the reset token : %s, %v.", tests.Failed, testID, gotID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to use the reset token.", tests.Success, testID)
+
+			if _, err := store.ConsumeReset(ctx, raw, now); !errors.Is(err, token.ErrResetUsed) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to use the reset token twice : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to use the reset token twice.", tests.Success, testID)
+		}
+	}
+}
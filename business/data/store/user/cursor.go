@@ -0,0 +1,65 @@
+package user
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/order"
+	"github.com/Avyukth/service3-clone/business/sys/database"
+)
+
+// cursor marks the position of the last row a client has seen for a given
+// ordering. It is handed out as an opaque base64 string.
+type cursor struct {
+	OrderBy string `json:"o"`
+	Value   string `json:"v"`
+	ID      string `json:"i"`
+}
+
+func encodeCursor(orderBy order.By, usr User) string {
+	c := cursor{
+		OrderBy: orderBy.Field + "," + orderBy.Direction,
+		Value:   cursorValue(orderBy.Field, usr),
+		ID:      usr.ID,
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(orderBy order.By, s string) (cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, database.ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return cursor{}, database.ErrInvalidCursor
+	}
+
+	// A cursor is only meaningful for the ordering it was produced with.
+	if c.OrderBy != orderBy.Field+","+orderBy.Direction {
+		return cursor{}, database.ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+func cursorValue(field string, usr User) string {
+	switch field {
+	case "name":
+		return usr.Name
+	case "email":
+		return usr.Email
+	case "date_created":
+		return usr.DateCreated.Format(time.RFC3339Nano)
+	default:
+		return usr.ID
+	}
+}
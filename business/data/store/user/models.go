@@ -1,19 +1,27 @@
 package user
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/Avyukth/service3-clone/business/sys/auth"
 )
 
 type User struct {
-	ID           string    `db:"user_id" json:"id"`
-	Name         string    `db:"name" json:"name"`
-	Email        string    `db:"email" json:"email"`
-	Roles        string    `db:"roles" json:"roles"`
-	PasswordHash []byte    `db:"password_hash" json:"-"`
-	DateCreated  time.Time `db:"date_created" json:"date_created"`
-	DateUpdated  time.Time `db:"date_updated" json:"date_updated"`
+	ID           string     `db:"user_id" json:"id"`
+	TenantID     string     `db:"tenant_id" json:"tenant_id"`
+	Name         string     `db:"name" json:"name"`
+	Email        string     `db:"email" json:"email"`
+	Roles        auth.Roles `db:"roles" json:"roles"`
+	PasswordHash []byte     `db:"password_hash" json:"-"`
+	FailedLogins int        `db:"failed_logins" json:"-"`
+	LockedUntil  *time.Time `db:"locked_until" json:"-"`
+	DateVerified *time.Time `db:"date_verified" json:"date_verified"`
+	DeletedAt    *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	Version      int        `db:"version" json:"version"`
+	DateCreated  time.Time  `db:"date_created" json:"date_created"`
+	DateUpdated  time.Time  `db:"date_updated" json:"date_updated"`
 }
 
 type NewUser struct {
@@ -31,3 +39,46 @@ type UpdateUser struct {
 	Password        *string     `json:"password"`
 	PasswordConfirm *string     `json:"password_confirm" validate:"omitempty,eqfield=Password"`
 }
+
+// UpdateProfile is what users can change about themselves. Roles and the
+// password are deliberately absent.
+type UpdateProfile struct {
+	Name  *string `json:"name"`
+	Email *string `json:"email" validate:"omitempty,email"`
+}
+
+// ChangePassword is what users provide to change their own password.
+type ChangePassword struct {
+	PasswordCurrent string `json:"password_current" validate:"required"`
+	Password        string `json:"password" validate:"required"`
+	PasswordConfirm string `json:"password_confirm" validate:"eqfield=Password"`
+}
+
+// QueryFilter holds the available fields a query can be filtered on.
+type QueryFilter struct {
+	Name             *string    `json:"name" validate:"omitempty,min=1"`
+	Email            *string    `json:"email" validate:"omitempty,email"`
+	Role             *auth.Role `json:"role"`
+	StartCreatedDate *time.Time `json:"start_created_date"`
+	EndCreatedDate   *time.Time `json:"end_created_date"`
+
+	// Deleted selects soft deleted users instead of active ones.
+	Deleted bool `json:"-"`
+}
+
+// ErrEmailExists is returned when another user already has the email address.
+var ErrEmailExists = errors.New("email address already in use")
+
+// ErrUnverified is returned by Authenticate when the user has not confirmed
+// their email address yet.
+var ErrUnverified = errors.New("email address has not been verified")
+
+// LockedError is returned by Authenticate while an account is locked after
+// too many failed login attempts.
+type LockedError struct {
+	Until time.Time
+}
+
+func (le *LockedError) Error() string {
+	return fmt.Sprintf("account is locked until %s", le.Until.Format(time.RFC3339))
+}
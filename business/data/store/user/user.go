@@ -1,20 +1,41 @@
 package user
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/Avyukth/service3-clone/business/data/order"
 	"github.com/Avyukth/service3-clone/business/sys/auth"
 	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/policy"
 	"github.com/Avyukth/service3-clone/business/sys/validate"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Failed logins below the threshold are allowed without delay. Reaching it
+// locks the account for lockoutBase, doubling with each further failure up to
+// lockoutMax.
+const (
+	lockoutThreshold = 5
+	lockoutBase      = time.Minute
+	lockoutMax       = time.Hour
+)
+
+// uniqueViolation is the Postgres error code for a unique constraint violation.
+const uniqueViolation = "23505"
+
+// AnyVersion can be passed as the expected version to skip the optimistic
+// concurrency check. Stored versions start at 1.
+const AnyVersion = 0
+
 type Store struct {
 	log *zap.SugaredLogger
 	db  *sqlx.DB
@@ -27,7 +48,22 @@ func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
 	}
 }
 
-func (s Store) Create(ctx context.Context, nu NewUser, now time.Time) (User, error) {
+// Create adds a user to the tenant that can log in straight away.
+func (s Store) Create(ctx context.Context, tenantID string, nu NewUser, now time.Time) (User, error) {
+	return s.create(ctx, tenantID, nu, &now)
+}
+
+// CreateUnverified adds a user to the tenant that cannot log in until Verify
+// is called.
+func (s Store) CreateUnverified(ctx context.Context, tenantID string, nu NewUser, now time.Time) (User, error) {
+	return s.create(ctx, tenantID, nu, nil)
+}
+
+func (s Store) create(ctx context.Context, tenantID string, nu NewUser, verified *time.Time) (User, error) {
+
+	if err := validate.CheckID(tenantID); err != nil {
+		return User{}, database.ErrInvalidID
+	}
 
 	if err := validate.Check(nu); err != nil {
 		return User{}, fmt.Errorf("validating data: %w", err)
@@ -41,115 +77,388 @@ func (s Store) Create(ctx context.Context, nu NewUser, now time.Time) (User, err
 
 	usr := User{
 		ID:           validate.GenerateID(),
+		TenantID:     tenantID,
 		Name:         nu.Name,
 		Email:        nu.Email,
 		PasswordHash: hash,
-		Roles:        convToString(nu.Roles),
+		Roles:        nu.Roles,
+		DateVerified: verified,
 		// DateCreated:  now,
 		// DateUpdated:  now,
 	}
 	const q = `
 	INSERT INTO users
-		(user_id, name, email, password_hash, roles)
+		(user_id, tenant_id, name, email, password_hash, roles, date_verified)
 	VALUES
-		(:user_id, :name, :email, :password_hash, :roles)`
+		(:user_id, :tenant_id, :name, :email, :password_hash, :roles, :date_verified)`
 
 	if err := database.NamedExecContext(ctx, s.log, s.db, q, usr); err != nil {
+		if emailTaken(err) {
+			return User{}, ErrEmailExists
+		}
 		return User{}, fmt.Errorf("inserting user: %w", err)
 	}
 	return usr, nil
 }
 
-func (s Store) Update(ctx context.Context, claims auth.Claims, userID string, uu UpdateUser, now time.Time) error {
+// Verify marks the email address of the user as confirmed. Verifying an
+// already verified user keeps the original date.
+func (s Store) Verify(ctx context.Context, userID string, now time.Time) error {
 
 	if err := validate.CheckID(userID); err != nil {
 		return database.ErrInvalidID
 	}
 
-	if err := validate.Check(uu); err != nil {
-		return fmt.Errorf("validating data: %w", err)
+	data := struct {
+		UserID       string    `db:"user_id"`
+		DateVerified time.Time `db:"date_verified"`
+	}{
+		UserID:       userID,
+		DateVerified: now,
 	}
 
-	usr, err := s.QueryByID(ctx, claims, userID)
-	if err != nil {
-		return fmt.Errorf("updating user userID[%s]: %w", userID, err)
-	}
+	const q = `
+	UPDATE
+		users
+	SET
+		"date_verified" = COALESCE(date_verified, :date_verified)
+	WHERE
+		user_id = :user_id AND
+		deleted_at IS NULL`
 
-	if uu.Name != nil {
-		usr.Name = *uu.Name
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("verifying user userID[%s]: %w", userID, err)
 	}
-	if uu.Email != nil {
-		usr.Email = *uu.Email
+
+	return nil
+}
+
+// Update applies the changes to the user. The update only happens when the
+// stored version still matches version, otherwise ErrVersionConflict is
+// returned.
+func (s Store) Update(ctx context.Context, claims auth.Claims, userID string, uu UpdateUser, version int, now time.Time) error {
+
+	if err := validate.CheckID(userID); err != nil {
+		return database.ErrInvalidID
 	}
-	if uu.Roles != nil {
-		usr.Roles = convToString(uu.Roles)
+
+	if err := validate.Check(uu); err != nil {
+		return fmt.Errorf("validating data: %w", err)
 	}
 
-	if uu.Password != nil {
-		pw, err := bcrypt.GenerateFromPassword([]byte(*uu.Password), bcrypt.DefaultCost)
+	f := func(ctx context.Context) error {
+		usr, err := s.QueryByID(ctx, claims, userID)
 		if err != nil {
-			return fmt.Errorf("generating password hash: %w", err)
+			return fmt.Errorf("updating user userID[%s]: %w", userID, err)
+		}
+
+		if !policy.Allowed(claims, policy.UserUpdate, usr.resource()) {
+			return database.ErrForbidden
+		}
+
+		if version != AnyVersion && usr.Version != version {
+			return database.ErrVersionConflict
+		}
+
+		if uu.Name != nil {
+			usr.Name = *uu.Name
 		}
-		usr.PasswordHash = pw
+		if uu.Email != nil {
+			usr.Email = *uu.Email
+		}
+		if uu.Roles != nil {
+			if !policy.Allowed(claims, policy.UserAssignRoles, usr.resource()) {
+				return database.ErrForbidden
+			}
+
+			// Only super admins can make or unmake a super admin.
+			if hasRole(uu.Roles, auth.RoleSuperAdmin) || hasRole(usr.Roles, auth.RoleSuperAdmin) {
+				if !policy.Allowed(claims, policy.UserGrantSuperAdmin, usr.resource()) {
+					return database.ErrForbidden
+				}
+			}
+			usr.Roles = uu.Roles
+		}
+
+		if uu.Password != nil {
+			if !policy.Allowed(claims, policy.UserSetPassword, usr.resource()) {
+				return database.ErrForbidden
+			}
+			pw, err := bcrypt.GenerateFromPassword([]byte(*uu.Password), bcrypt.DefaultCost)
+			if err != nil {
+				return fmt.Errorf("generating password hash: %w", err)
+			}
+			usr.PasswordHash = pw
+
+		}
+		usr.DateUpdated = now
+		const q = `
+		UPDATE
+			users
+		SET
+			"name"=:name,
+			"email"=:email,
+			"roles"=:roles,
+			"password_hash"=:password_hash,
+			"date_updated"=:date_updated,
+			"version"=version + 1
+		WHERE user_id=:user_id AND tenant_id=:tenant_id AND version=:version AND deleted_at IS NULL
+		RETURNING version`
+
+		// A concurrent update between the read and the write leaves no row
+		// with the version that was read.
+		var res struct {
+			Version int `db:"version"`
+		}
+		if err := database.NamedQueryStruct(ctx, s.log, s.db, q, usr, &res); err != nil {
+			if err == database.ErrNotFound {
+				return database.ErrVersionConflict
+			}
+			if emailTaken(err) {
+				return ErrEmailExists
+			}
+			return fmt.Errorf("updating user userID[%s]: %w", userID, err)
+		}
+		return nil
+	}
+
+	return database.WithinTran(ctx, s.db, f)
+}
+
+// UpdatePassword replaces the password of the user and clears any lockout
+// from failed logins.
+func (s Store) UpdatePassword(ctx context.Context, userID string, password string, now time.Time) error {
+
+	if err := validate.CheckID(userID); err != nil {
+		return database.ErrInvalidID
+	}
 
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("generating password hash: %w", err)
+	}
+
+	data := struct {
+		UserID       string    `db:"user_id"`
+		PasswordHash []byte    `db:"password_hash"`
+		DateUpdated  time.Time `db:"date_updated"`
+	}{
+		UserID:       userID,
+		PasswordHash: hash,
+		DateUpdated:  now,
 	}
-	usr.DateUpdated = now
+
 	const q = `
 	UPDATE
 		users
 	SET
-		"name"=:name,
-		"email"=:email,
-		"roles"=:roles,
-		"date_updated"=:date_updated
-	WHERE user_id=:user_id`
-	if err := database.NamedExecContext(ctx, s.log, s.db, q, usr); err != nil {
-		return fmt.Errorf("updating user userID[%s]: %w", userID, err)
+		"password_hash" = :password_hash,
+		"failed_logins" = 0,
+		"locked_until" = NULL,
+		"date_updated" = :date_updated,
+		"version" = version + 1
+	WHERE
+		user_id = :user_id AND
+		deleted_at IS NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("updating password userID[%s]: %w", userID, err)
 	}
+
 	return nil
 }
 
-func (s Store) Delete(ctx context.Context, claims auth.Claims, userID string) error {
+// ChangePassword replaces the password of the user after checking the current
+// one. Only the user themselves can do this.
+func (s Store) ChangePassword(ctx context.Context, claims auth.Claims, userID string, cp ChangePassword, now time.Time) error {
+
+	if err := validate.Check(cp); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	f := func(ctx context.Context) error {
+		usr, err := s.QueryByID(ctx, claims, userID)
+		if err != nil {
+			return fmt.Errorf("changing password userID[%s]: %w", userID, err)
+		}
+
+		if !policy.Allowed(claims, policy.UserChangePassword, usr.resource()) {
+			return database.ErrForbidden
+		}
+
+		if err := bcrypt.CompareHashAndPassword(usr.PasswordHash, []byte(cp.PasswordCurrent)); err != nil {
+			return database.ErrAuthenticationFailure
+		}
+
+		return s.UpdatePassword(ctx, userID, cp.Password, now)
+	}
+
+	return database.WithinTran(ctx, s.db, f)
+}
+
+// Delete soft deletes the user. The row is kept, and can be restored, until
+// it is purged. Like Update it only happens when the version still matches.
+func (s Store) Delete(ctx context.Context, claims auth.Claims, userID string, version int, now time.Time) error {
+
+	if err := validate.CheckID(userID); err != nil {
+		return database.ErrInvalidID
+	}
+
+	f := func(ctx context.Context) error {
+		usr, err := s.QueryByID(ctx, claims, userID)
+		if err != nil {
+			return fmt.Errorf("deleting user userID[%s]: %w", userID, err)
+		}
+
+		if !policy.Allowed(claims, policy.UserDelete, usr.resource()) {
+			return database.ErrForbidden
+		}
+
+		if version != AnyVersion && usr.Version != version {
+			return database.ErrVersionConflict
+		}
+
+		data := struct {
+			UserID    string    `db:"user_id"`
+			Version   int       `db:"version"`
+			DeletedAt time.Time `db:"deleted_at"`
+		}{
+			UserID:    userID,
+			Version:   usr.Version,
+			DeletedAt: now,
+		}
+
+		const q = `
+		UPDATE
+			users
+		SET
+			"deleted_at" = :deleted_at,
+			"version" = version + 1
+		WHERE
+			user_id = :user_id AND
+			version = :version AND
+			deleted_at IS NULL
+		RETURNING
+			user_id`
+
+		var res struct {
+			UserID string `db:"user_id"`
+		}
+		if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+			if err == database.ErrNotFound {
+				return database.ErrVersionConflict
+			}
+			return fmt.Errorf("deleting user userID[%s]: %w", userID, err)
+		}
+
+		return nil
+	}
+
+	return database.WithinTran(ctx, s.db, f)
+}
+
+// Restore brings back a soft deleted user of the tenant of the claims.
+func (s Store) Restore(ctx context.Context, claims auth.Claims, userID string, now time.Time) error {
 
 	if err := validate.CheckID(userID); err != nil {
 		return database.ErrInvalidID
 	}
 
-	if !claims.Authorized(auth.RoleAdmin) && claims.Subject != userID {
+	if !policy.Allowed(claims, policy.UserRestore, policy.Resource{OwnerID: userID, TenantID: claims.TenantID}) {
 		return database.ErrForbidden
 	}
 
 	data := struct {
-		UserID string `db:"user_id"`
+		UserID      string    `db:"user_id"`
+		TenantID    string    `db:"tenant_id"`
+		DateUpdated time.Time `db:"date_updated"`
 	}{
-		UserID: userID,
+		UserID:      userID,
+		TenantID:    claims.TenantID,
+		DateUpdated: now,
 	}
 
 	const q = `
-		DELETE FROM
-			users
-		WHERE
-			user_id=:user_id`
+	UPDATE
+		users
+	SET
+		"deleted_at" = NULL,
+		"date_updated" = :date_updated,
+		"version" = version + 1
+	WHERE
+		user_id = :user_id AND
+		tenant_id = :tenant_id AND
+		deleted_at IS NOT NULL
+	RETURNING
+		user_id`
 
-	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
-		return fmt.Errorf("deleting user userID[%s]: %w", userID, err)
+	var res struct {
+		UserID string `db:"user_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		if err == database.ErrNotFound {
+			return database.ErrNotFound
+		}
+		return fmt.Errorf("restoring user userID[%s]: %w", userID, err)
 	}
 
 	return nil
 }
 
+// Purge permanently removes users of the tenant of the claims that were soft
+// deleted before the cutoff and returns the ids of the removed users.
+func (s Store) Purge(ctx context.Context, claims auth.Claims, cutoff time.Time) ([]string, error) {
+
+	if !policy.Allowed(claims, policy.UserPurge, policy.Resource{TenantID: claims.TenantID}) {
+		return nil, database.ErrForbidden
+	}
+
+	data := struct {
+		TenantID string    `db:"tenant_id"`
+		Cutoff   time.Time `db:"cutoff"`
+	}{
+		TenantID: claims.TenantID,
+		Cutoff:   cutoff,
+	}
+
+	const q = `
+	DELETE FROM
+		users
+	WHERE
+		tenant_id = :tenant_id AND
+		deleted_at IS NOT NULL AND
+		deleted_at < :cutoff
+	RETURNING
+		user_id`
+
+	var res []struct {
+		UserID string `db:"user_id"`
+	}
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &res); err != nil {
+		return nil, fmt.Errorf("purging users: %w", err)
+	}
+
+	ids := make([]string, len(res))
+	for i, r := range res {
+		ids[i] = r.UserID
+	}
+
+	return ids, nil
+}
+
+// QueryByID returns the user from the tenant of the claims. Users of other
+// tenants are reported as not found.
 func (s Store) QueryByID(ctx context.Context, claims auth.Claims, userID string) (User, error) {
 
 	if err := validate.CheckID(userID); err != nil {
 		return User{}, database.ErrInvalidID
 	}
-	if !claims.Authorized(auth.RoleAdmin) && claims.Subject != userID {
-		return User{}, database.ErrForbidden
-	}
 	data := struct {
-		UserID string `db:"user_id"`
+		UserID   string `db:"user_id"`
+		TenantID string `db:"tenant_id"`
 	}{
-		UserID: userID,
+		UserID:   userID,
+		TenantID: claims.TenantID,
 	}
 	const q = `
 	SELECT
@@ -157,7 +466,9 @@ func (s Store) QueryByID(ctx context.Context, claims auth.Claims, userID string)
 	FROM
 		users
 	WHERE
-		user_id = :user_id`
+		user_id = :user_id AND
+		tenant_id = :tenant_id AND
+		deleted_at IS NULL`
 
 	var usr User
 
@@ -169,38 +480,163 @@ func (s Store) QueryByID(ctx context.Context, claims auth.Claims, userID string)
 		return User{}, fmt.Errorf("selecting user userID[%s]: %w", userID, err)
 	}
 
+	if !policy.Allowed(claims, policy.UserRead, usr.resource()) {
+		return User{}, database.ErrForbidden
+	}
+
 	return usr, nil
 }
 
-func (s Store) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]User, error) {
+// OrderByFields is the allow-list of fields users can be ordered by.
+var OrderByFields = map[string]string{
+	"user_id":      "user_id",
+	"name":         "name",
+	"email":        "email",
+	"date_created": "date_created",
+}
 
-	data := struct {
-		Offset      int `db:"offset"`
-		RowsPerPage int `db:"rows_per_page"`
-	}{
-		Offset:      (pageNumber - 1) * rowsPerPage,
-		RowsPerPage: rowsPerPage,
+// DefaultOrderBy is the ordering used when none is requested.
+var DefaultOrderBy = order.NewBy("user_id", order.ASC)
+
+// Query returns a page of the users of the tenant of the claims matching the
+// filter using keyset pagination. The returned cursor is empty when there are
+// no more rows.
+func (s Store) Query(ctx context.Context, claims auth.Claims, filter QueryFilter, orderBy order.By, after string, rowsPerPage int) ([]User, string, error) {
+
+	if err := validate.Check(filter); err != nil {
+		return nil, "", fmt.Errorf("validating filter: %w", err)
+	}
+
+	field, exists := OrderByFields[orderBy.Field]
+	if !exists {
+		return nil, "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+
+	data := map[string]interface{}{
+		"rows_per_page": rowsPerPage + 1,
+	}
+
+	wc := filterClauses(claims.TenantID, filter, data)
+
+	if after != "" {
+		c, err := decodeCursor(orderBy, after)
+		if err != nil {
+			return nil, "", err
+		}
+
+		op := ">"
+		if orderBy.Direction == order.DESC {
+			op = "<"
+		}
+
+		data["cursor_value"] = c.Value
+		data["cursor_id"] = c.ID
+		wc = append(wc, fmt.Sprintf("(%s, user_id) %s (:cursor_value, :cursor_id)", field, op))
 	}
 
 	const q = `
 	SELECT
 		*
 	FROM
-		users
+		users`
+
+	buf := bytes.NewBufferString(q)
+	writeWhere(buf, wc)
+	buf.WriteString(fmt.Sprintf(`
 	ORDER BY
-		user_id
-	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+		%[1]s %[2]s, user_id %[2]s
+	FETCH NEXT :rows_per_page ROWS ONLY`, field, orderBy.Direction))
 
 	var users []User
-	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &users); err != nil {
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &users); err != nil {
 		if err == database.ErrNotFound {
-			return nil, database.ErrNotFound
+			return nil, "", database.ErrNotFound
 		}
-		return nil, fmt.Errorf("selecting users: %w", err)
+		return nil, "", fmt.Errorf("selecting users: %w", err)
+	}
+
+	var next string
+	if len(users) > rowsPerPage {
+		users = users[:rowsPerPage]
+		next = encodeCursor(orderBy, users[len(users)-1])
+	}
+
+	return users, next, nil
+}
+
+// Count returns the total number of users of the tenant of the claims
+// matching the filter.
+func (s Store) Count(ctx context.Context, claims auth.Claims, filter QueryFilter) (int, error) {
+
+	if err := validate.Check(filter); err != nil {
+		return 0, fmt.Errorf("validating filter: %w", err)
+	}
+
+	data := map[string]interface{}{}
+
+	const q = `
+	SELECT
+		count(1) AS count
+	FROM
+		users`
+
+	buf := bytes.NewBufferString(q)
+	writeWhere(buf, filterClauses(claims.TenantID, filter, data))
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("counting users: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+func filterClauses(tenantID string, filter QueryFilter, data map[string]interface{}) []string {
+	data["tenant_id"] = tenantID
+	wc := []string{"tenant_id = :tenant_id", "deleted_at IS NULL"}
+	if filter.Deleted {
+		wc[1] = "deleted_at IS NOT NULL"
+	}
+
+	if filter.Name != nil {
+		data["name"] = "%" + *filter.Name + "%"
+		wc = append(wc, "name ILIKE :name")
+	}
+
+	if filter.Email != nil {
+		data["email"] = *filter.Email
+		wc = append(wc, "email = :email")
+	}
+
+	if filter.Role != nil {
+		data["role"] = filter.Role.Name()
+		wc = append(wc, ":role = ANY(roles)")
+	}
+
+	if filter.StartCreatedDate != nil {
+		data["start_created_date"] = filter.StartCreatedDate.UTC()
+		wc = append(wc, "date_created >= :start_created_date")
+	}
+
+	if filter.EndCreatedDate != nil {
+		data["end_created_date"] = filter.EndCreatedDate.UTC()
+		wc = append(wc, "date_created <= :end_created_date")
 	}
 
-	return users, nil
+	return wc
+}
+
+func writeWhere(buf *bytes.Buffer, wc []string) {
+	if len(wc) == 0 {
+		return
+	}
 
+	buf.WriteString(`
+	WHERE
+		`)
+	buf.WriteString(strings.Join(wc, " AND\n\t\t"))
 }
 
 func (s Store) QueryByEmail(ctx context.Context, claims auth.Claims, email string) (User, error) {
@@ -210,9 +646,11 @@ func (s Store) QueryByEmail(ctx context.Context, claims auth.Claims, email strin
 	}
 
 	data := struct {
-		Email string `db:"email"`
+		Email    string `db:"email"`
+		TenantID string `db:"tenant_id"`
 	}{
-		Email: email,
+		Email:    email,
+		TenantID: claims.TenantID,
 	}
 
 	const q = `
@@ -221,7 +659,9 @@ func (s Store) QueryByEmail(ctx context.Context, claims auth.Claims, email strin
 	FROM
 		users
 	WHERE
-		email = :email`
+		email = :email AND
+		tenant_id = :tenant_id AND
+		deleted_at IS NULL`
 
 	var usr User
 	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &usr); err != nil {
@@ -231,16 +671,20 @@ func (s Store) QueryByEmail(ctx context.Context, claims auth.Claims, email strin
 		return User{}, fmt.Errorf("selecting user email[%s]: %w", email, err)
 	}
 
-	if !claims.Authorized(auth.RoleAdmin) && claims.Subject != usr.ID {
+	if !policy.Allowed(claims, policy.UserRead, usr.resource()) {
 		return User{}, database.ErrForbidden
 	}
 
 	return usr, nil
 }
 
-func (s Store) Authenticate(ctx context.Context, now time.Time, email string, password string) (auth.Claims, error) {
+// LookupByEmail returns the user with the specified email whatever their
+// tenant. Emails are unique across tenants. It is meant for flows the system
+// runs for a user that is not logged in, so no policy is checked.
+func (s Store) LookupByEmail(ctx context.Context, email string) (User, error) {
+
 	if err := validate.Email(email); err != nil {
-		return auth.Claims{}, database.ErrInvalidEmail
+		return User{}, database.ErrInvalidEmail
 	}
 
 	data := struct {
@@ -255,60 +699,258 @@ func (s Store) Authenticate(ctx context.Context, now time.Time, email string, pa
 	FROM
 		users
 	WHERE
-	email = :email`
+		email = :email AND
+		deleted_at IS NULL`
 
 	var usr User
 	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &usr); err != nil {
 		if err == database.ErrNotFound {
-			return auth.Claims{}, database.ErrNotFound
+			return User{}, database.ErrNotFound
 		}
-		return auth.Claims{}, fmt.Errorf("selecting user[%q]: %w", email, err)
+		return User{}, fmt.Errorf("selecting user email[%s]: %w", email, err)
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(usr.PasswordHash), []byte(password)); err != nil {
-		return auth.Claims{}, database.ErrAuthenticationFailure
+	return usr, nil
+}
+
+// LookupByID returns the user with the specified id whatever their tenant. Like
+// LookupByEmail it is meant for flows the system runs for a user that is not
+// logged in.
+func (s Store) LookupByID(ctx context.Context, userID string) (User, error) {
+
+	if err := validate.CheckID(userID); err != nil {
+		return User{}, database.ErrInvalidID
 	}
 
-	roles, err := convToRoles(usr.Roles)
-	if err != nil {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		users
+	WHERE
+		user_id = :user_id AND
+		deleted_at IS NULL`
+
+	var usr User
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &usr); err != nil {
+		if err == database.ErrNotFound {
+			return User{}, database.ErrNotFound
+		}
+		return User{}, fmt.Errorf("selecting user userID[%s]: %w", userID, err)
+	}
+
+	return usr, nil
+}
+
+// Authenticate verifies the password of the user with the specified email.
+// Failed attempts are counted per account and once lockoutThreshold is reached
+// the account is locked for a period that doubles with every further failure.
+// A locked account returns a *LockedError without checking the password and
+// an account whose email is not verified returns ErrUnverified.
+func (s Store) Authenticate(ctx context.Context, now time.Time, email string, password string) (auth.Claims, error) {
+	if err := validate.Email(email); err != nil {
+		return auth.Claims{}, database.ErrInvalidEmail
+	}
+
+	var usr User
+	var failed bool
+
+	f := func(ctx context.Context) error {
+		data := struct {
+			Email string `db:"email"`
+		}{
+			Email: email,
+		}
+
+		// Users of a suspended tenant can not log in.
+		const q = `
+		SELECT
+			u.*
+		FROM
+			users AS u
+		JOIN
+			tenants AS t ON t.tenant_id = u.tenant_id
+		WHERE
+			u.email = :email AND
+			u.deleted_at IS NULL AND
+			t.active
+		FOR UPDATE OF u`
+
+		if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &usr); err != nil {
+			if err == database.ErrNotFound {
+				return database.ErrNotFound
+			}
+			return fmt.Errorf("selecting user[%q]: %w", email, err)
+		}
+
+		if usr.LockedUntil != nil && now.Before(*usr.LockedUntil) {
+			return &LockedError{Until: *usr.LockedUntil}
+		}
+
+		if err := bcrypt.CompareHashAndPassword(usr.PasswordHash, []byte(password)); err != nil {
+			failed = true
+			return s.recordLogin(ctx, usr, usr.FailedLogins+1, now)
+		}
+
+		if usr.FailedLogins == 0 {
+			return nil
+		}
+		return s.recordLogin(ctx, usr, 0, now)
+	}
+
+	// The failure count must be committed, so the authentication error is
+	// only reported once the transaction has finished.
+	if err := database.WithinTran(ctx, s.db, f); err != nil {
 		return auth.Claims{}, err
 	}
+	if failed {
+		return auth.Claims{}, database.ErrAuthenticationFailure
+	}
+	if usr.DateVerified == nil {
+		return auth.Claims{}, ErrUnverified
+	}
+
+	return newClaims(usr, now), nil
+}
+
+// recordLogin stores the number of consecutive failed logins for the user and
+// locks the account when the count calls for it.
+func (s Store) recordLogin(ctx context.Context, usr User, failedLogins int, now time.Time) error {
+
+	data := struct {
+		UserID       string     `db:"user_id"`
+		FailedLogins int        `db:"failed_logins"`
+		LockedUntil  *time.Time `db:"locked_until"`
+	}{
+		UserID:       usr.ID,
+		FailedLogins: failedLogins,
+	}
+
+	if d := lockoutDuration(failedLogins); d > 0 {
+		until := now.Add(d)
+		data.LockedUntil = &until
+		s.log.Infow("user locked", "userid", usr.ID, "failed_logins", failedLogins, "until", until)
+	}
+
+	const q = `
+	UPDATE
+		users
+	SET
+		"failed_logins" = :failed_logins,
+		"locked_until" = :locked_until
+	WHERE
+		user_id = :user_id AND
+		deleted_at IS NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("recording login userID[%s]: %w", usr.ID, err)
+	}
 
+	return nil
+}
+
+// lockoutDuration returns how long an account is locked after the specified
+// number of consecutive failed logins.
+func lockoutDuration(failedLogins int) time.Duration {
+	if failedLogins < lockoutThreshold {
+		return 0
+	}
+
+	d := lockoutBase
+	for i := lockoutThreshold; i < failedLogins && d < lockoutMax; i++ {
+		d *= 2
+	}
+	if d > lockoutMax {
+		d = lockoutMax
+	}
+
+	return d
+}
+
+// Claims builds a fresh set of claims for the user with the specified id. It is
+// used when tokens are reissued without the user presenting a password, which
+// stops working once the tenant of the user is suspended.
+func (s Store) Claims(ctx context.Context, now time.Time, userID string) (auth.Claims, error) {
+
+	if err := validate.CheckID(userID); err != nil {
+		return auth.Claims{}, database.ErrInvalidID
+	}
+
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		u.*
+	FROM
+		users AS u
+	JOIN
+		tenants AS t ON t.tenant_id = u.tenant_id
+	WHERE
+		u.user_id = :user_id AND
+		u.deleted_at IS NULL AND
+		t.active`
+
+	var usr User
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &usr); err != nil {
+		if err == database.ErrNotFound {
+			return auth.Claims{}, database.ErrNotFound
+		}
+		return auth.Claims{}, fmt.Errorf("selecting user userID[%s]: %w", userID, err)
+	}
+
+	return newClaims(usr, now), nil
+}
+
+func newClaims(usr User, now time.Time) auth.Claims {
 	claims := auth.Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    "Service Project",
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			Subject:   usr.ID,
+			ID:        validate.GenerateID(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
-		Roles: roles,
+		Roles:    usr.Roles,
+		TenantID: usr.TenantID,
 	}
 
-	return claims, nil
+	return claims
 }
 
-func convToString(uRoles []auth.Role) string {
-	roles := make([]string, len(uRoles))
-	rStr := ""
-	for i, role := range uRoles {
-		roles[i] = role.Name()
+// emailTaken reports whether the error is the unique violation of the email
+// column, which is unique across tenants.
+func emailTaken(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
 	}
-	rStr = strings.Join(roles, ", ")
-
-	return rStr
+	return pqErr.Code == uniqueViolation && pqErr.Constraint == "users_email_key"
 }
 
-func convToRoles(rStr string) ([]auth.Role, error) {
-	roleNames := strings.Split(rStr, ",")
-	roles := make([]auth.Role, len(roleNames))
+// resource describes the user to the authorization policy.
+func (u User) resource() policy.Resource {
+	return policy.Resource{
+		OwnerID:  u.ID,
+		TenantID: u.TenantID,
+	}
+}
 
-	for i, roleName := range roleNames {
-		role, err := auth.ParseRole(strings.TrimSpace(roleName))
-		if err != nil {
-			return nil, err // or handle the error in another way if you prefer
+func hasRole(roles []auth.Role, role auth.Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
 		}
-		roles[i] = role
 	}
-
-	return roles, nil
+	return false
 }
@@ -2,14 +2,15 @@ package user_test
 
 import (
 	"context"
-	// "errors"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/Avyukth/service3-clone/business/data/store/tenant"
 	"github.com/Avyukth/service3-clone/business/data/store/user"
 	"github.com/Avyukth/service3-clone/business/data/tests"
 	"github.com/Avyukth/service3-clone/business/sys/auth"
-	// "github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/database"
 	"github.com/golang-jwt/jwt/v5"
 	// "github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
@@ -47,12 +48,17 @@ func TestUser(t *testing.T) {
 				PasswordConfirm: "gophers",
 			}
 
-			usr, err := store.Create(ctx, nu, now)
+			usr, err := store.Create(ctx, tenant.DefaultID, nu, now)
 			if err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to create user : %s.", tests.Failed, testID, err)
 			}
 			t.Logf("\t%s\tTest %d:\tShould be able to create user.", tests.Success, testID)
 
+			if _, err := store.Create(ctx, tenant.DefaultID, nu, now); !errors.Is(err, user.ErrEmailExists) {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to create a user with the same email : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to create a user with the same email.", tests.Success, testID)
+
 			jwtId := uuid.New().String()
 
 			claims := auth.Claims{
@@ -66,7 +72,8 @@ func TestUser(t *testing.T) {
 					ID:        jwtId,
 					Audience:  []string{"https://subhrajit.eth"},
 				},
-				Roles: []auth.Role{auth.RoleUser},
+				Roles:    []auth.Role{auth.RoleUser},
+				TenantID: tenant.DefaultID,
 			}
 
 			_, err = store.QueryByID(ctx, claims, usr.ID)
@@ -134,4 +141,130 @@ func TestUser(t *testing.T) {
 			// t.Logf("\t%s\tTest %d:\tShould not be able to retrieve user.", tests.Success, testID)
 		}
 	}
+	t.Log("Given the need to lock accounts after repeated failed logins.")
+	{
+		testID := 1
+
+		t.Logf("\t Test %d:\tWhen the wrong password is used too often.", testID)
+		{
+			ctx := context.Background()
+			now := time.Now().UTC()
+
+			nu := user.NewUser{
+				Name:            "Locked Gopher",
+				Email:           "locked@example.com",
+				Roles:           []auth.Role{auth.RoleUser},
+				Password:        "gophers",
+				PasswordConfirm: "gophers",
+			}
+
+			if _, err := store.Create(ctx, tenant.DefaultID, nu, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create user : %s.", tests.Failed, testID, err)
+			}
+
+			for i := 0; i < 5; i++ {
+				if _, err := store.Authenticate(ctx, now, nu.Email, "wrong"); !errors.Is(err, database.ErrAuthenticationFailure) {
+					t.Fatalf("\t%s\tTest %d:\tShould fail to authenticate with the wrong password : %v.", tests.Failed, testID, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould fail to authenticate with the wrong password.", tests.Success, testID)
+
+			var locked *user.LockedError
+			if _, err := store.Authenticate(ctx, now, nu.Email, nu.Password); !errors.As(err, &locked) {
+				t.Fatalf("\t%s\tTest %d:\tShould refuse the right password while locked : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould refuse the right password while locked.", tests.Success, testID)
+
+			if _, err := store.Authenticate(ctx, locked.Until, nu.Email, nu.Password); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould authenticate once the lock expires : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould authenticate once the lock expires.", tests.Success, testID)
+		}
+	}
+	t.Log("Given the need to soft delete and restore users.")
+	{
+		testID := 2
+
+		t.Logf("\t Test %d:\tWhen deleting a user.", testID)
+		{
+			ctx := context.Background()
+			now := time.Now().UTC()
+
+			nu := user.NewUser{
+				Name:            "Deleted Gopher",
+				Email:           "deleted@example.com",
+				Roles:           []auth.Role{auth.RoleUser},
+				Password:        "gophers",
+				PasswordConfirm: "gophers",
+			}
+
+			usr, err := store.Create(ctx, tenant.DefaultID, nu, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create user : %s.", tests.Failed, testID, err)
+			}
+
+			admin := tests.Grant(t, log, db, auth.Claims{Roles: []auth.Role{auth.RoleAdmin}, TenantID: tenant.DefaultID})
+
+			if err := store.Delete(ctx, admin, usr.ID, user.AnyVersion, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete user : %s.", tests.Failed, testID, err)
+			}
+			if _, err := store.QueryByID(ctx, admin, usr.ID); !errors.Is(err, database.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould not find the deleted user : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not find the deleted user.", tests.Success, testID)
+
+			if err := store.Restore(ctx, admin, usr.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to restore user : %s.", tests.Failed, testID, err)
+			}
+			if _, err := store.QueryByID(ctx, admin, usr.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould find the restored user : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould find the restored user.", tests.Success, testID)
+
+			if err := store.Delete(ctx, admin, usr.ID, user.AnyVersion, now.Add(-time.Hour)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete user : %s.", tests.Failed, testID, err)
+			}
+			purged, err := store.Purge(ctx, admin, now)
+			if err != nil || len(purged) != 1 || purged[0] != usr.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould purge the deleted user : %v, %v.", tests.Failed, testID, purged, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould purge the deleted user.", tests.Success, testID)
+		}
+	}
+	t.Log("Given the need to prevent lost updates.")
+	{
+		testID := 3
+
+		t.Logf("\t Test %d:\tWhen updating with a stale version.", testID)
+		{
+			ctx := context.Background()
+			now := time.Now().UTC()
+
+			nu := user.NewUser{
+				Name:            "Versioned Gopher",
+				Email:           "versioned@example.com",
+				Roles:           []auth.Role{auth.RoleUser},
+				Password:        "gophers",
+				PasswordConfirm: "gophers",
+			}
+
+			usr, err := store.Create(ctx, tenant.DefaultID, nu, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create user : %s.", tests.Failed, testID, err)
+			}
+
+			admin := tests.Grant(t, log, db, auth.Claims{Roles: []auth.Role{auth.RoleAdmin}, TenantID: tenant.DefaultID})
+			upd := user.UpdateUser{Name: tests.StringPointer("Renamed Gopher")}
+
+			if err := store.Update(ctx, admin, usr.ID, upd, 1, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to update with the current version : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to update with the current version.", tests.Success, testID)
+
+			if err := store.Update(ctx, admin, usr.ID, upd, 1, now); !errors.Is(err, database.ErrVersionConflict) {
+				t.Fatalf("\t%s\tTest %d:\tShould reject an update with a stale version : %v.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould reject an update with a stale version.", tests.Success, testID)
+		}
+	}
 }
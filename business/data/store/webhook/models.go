@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// AllEvents subscribes a webhook to every event.
+const AllEvents = "*"
+
+// Set of states a delivery moves through. A delivery that keeps failing ends
+// up dead and is only tried again when an admin asks for it.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+// Webhook is a subscription of an external URL to events. The secret signs
+// every delivery and is only shown when the webhook is created.
+type Webhook struct {
+	ID          string         `db:"webhook_id" json:"id"`
+	URL         string         `db:"url" json:"url"`
+	Secret      string         `db:"secret" json:"-"`
+	Events      pq.StringArray `db:"events" json:"events"`
+	Active      bool           `db:"active" json:"active"`
+	DateCreated time.Time      `db:"date_created" json:"date_created"`
+	DateUpdated time.Time      `db:"date_updated" json:"date_updated"`
+}
+
+type NewWebhook struct {
+	URL    string   `json:"url" validate:"required,url"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=* user.created user.updated user.deleted sale.created"`
+}
+
+type UpdateWebhook struct {
+	URL    *string  `json:"url" validate:"omitempty,url"`
+	Events []string `json:"events" validate:"omitempty,min=1,dive,oneof=* user.created user.updated user.deleted sale.created"`
+	Active *bool    `json:"active"`
+}
+
+// Delivery is a single event sent, or to be sent, to a webhook.
+type Delivery struct {
+	ID             string          `db:"delivery_id" json:"id"`
+	WebhookID      string          `db:"webhook_id" json:"webhook_id"`
+	EventID        string          `db:"event_id" json:"event_id"`
+	EventName      string          `db:"event_name" json:"event_name"`
+	Payload        json.RawMessage `db:"payload" json:"payload"`
+	Status         string          `db:"status" json:"status"`
+	Attempts       int             `db:"attempts" json:"attempts"`
+	NextAttempt    time.Time       `db:"next_attempt" json:"next_attempt"`
+	LastStatusCode *int            `db:"last_status_code" json:"last_status_code"`
+	LastError      *string         `db:"last_error" json:"last_error"`
+	DateCreated    time.Time       `db:"date_created" json:"date_created"`
+	DateUpdated    time.Time       `db:"date_updated" json:"date_updated"`
+}
+
+// Pending is a delivery that is due together with where it goes.
+type Pending struct {
+	Delivery
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
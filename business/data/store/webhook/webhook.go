@@ -0,0 +1,415 @@
+// Package webhook stores webhook subscriptions and the history of the events
+// delivered to them.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/database"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		db:  db,
+	}
+}
+
+func (s Store) Create(ctx context.Context, nw NewWebhook, secret string, now time.Time) (Webhook, error) {
+
+	if err := validate.Check(nw); err != nil {
+		return Webhook{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	wh := Webhook{
+		ID:          validate.GenerateID(),
+		URL:         nw.URL,
+		Secret:      secret,
+		Events:      nw.Events,
+		Active:      true,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	const q = `
+	INSERT INTO webhooks
+		(webhook_id, url, secret, events, active, date_created, date_updated)
+	VALUES
+		(:webhook_id, :url, :secret, :events, :active, :date_created, :date_updated)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, wh); err != nil {
+		return Webhook{}, fmt.Errorf("inserting webhook: %w", err)
+	}
+
+	return wh, nil
+}
+
+func (s Store) Update(ctx context.Context, webhookID string, uw UpdateWebhook, now time.Time) error {
+
+	if err := validate.CheckID(webhookID); err != nil {
+		return database.ErrInvalidID
+	}
+
+	if err := validate.Check(uw); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	wh, err := s.QueryByID(ctx, webhookID)
+	if err != nil {
+		return fmt.Errorf("updating webhook webhookID[%s]: %w", webhookID, err)
+	}
+
+	if uw.URL != nil {
+		wh.URL = *uw.URL
+	}
+	if uw.Events != nil {
+		wh.Events = uw.Events
+	}
+	if uw.Active != nil {
+		wh.Active = *uw.Active
+	}
+	wh.DateUpdated = now
+
+	const q = `
+	UPDATE
+		webhooks
+	SET
+		"url" = :url,
+		"events" = :events,
+		"active" = :active,
+		"date_updated" = :date_updated
+	WHERE
+		webhook_id = :webhook_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, wh); err != nil {
+		return fmt.Errorf("updating webhook webhookID[%s]: %w", webhookID, err)
+	}
+
+	return nil
+}
+
+// Delete removes the webhook together with its delivery history.
+func (s Store) Delete(ctx context.Context, webhookID string) error {
+
+	if err := validate.CheckID(webhookID); err != nil {
+		return database.ErrInvalidID
+	}
+
+	data := struct {
+		WebhookID string `db:"webhook_id"`
+	}{
+		WebhookID: webhookID,
+	}
+
+	const q = `
+	DELETE FROM
+		webhooks
+	WHERE
+		webhook_id = :webhook_id
+	RETURNING
+		webhook_id`
+
+	var res struct {
+		WebhookID string `db:"webhook_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		if err == database.ErrNotFound {
+			return database.ErrNotFound
+		}
+		return fmt.Errorf("deleting webhook webhookID[%s]: %w", webhookID, err)
+	}
+
+	return nil
+}
+
+func (s Store) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]Webhook, error) {
+
+	data := struct {
+		Offset      int `db:"offset"`
+		RowsPerPage int `db:"rows_per_page"`
+	}{
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		webhooks
+	ORDER BY
+		date_created, webhook_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var whs []Webhook
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &whs); err != nil {
+		return nil, fmt.Errorf("selecting webhooks: %w", err)
+	}
+
+	return whs, nil
+}
+
+func (s Store) QueryByID(ctx context.Context, webhookID string) (Webhook, error) {
+
+	if err := validate.CheckID(webhookID); err != nil {
+		return Webhook{}, database.ErrInvalidID
+	}
+
+	data := struct {
+		WebhookID string `db:"webhook_id"`
+	}{
+		WebhookID: webhookID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		webhooks
+	WHERE
+		webhook_id = :webhook_id`
+
+	var wh Webhook
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &wh); err != nil {
+		if err == database.ErrNotFound {
+			return Webhook{}, database.ErrNotFound
+		}
+		return Webhook{}, fmt.Errorf("selecting webhook webhookID[%s]: %w", webhookID, err)
+	}
+
+	return wh, nil
+}
+
+// QueryByEvent returns the active webhooks subscribed to the named event.
+func (s Store) QueryByEvent(ctx context.Context, name string) ([]Webhook, error) {
+
+	data := struct {
+		Name string `db:"name"`
+		All  string `db:"all"`
+	}{
+		Name: name,
+		All:  AllEvents,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		webhooks
+	WHERE
+		active AND
+		(:name = ANY(events) OR :all = ANY(events))`
+
+	var whs []Webhook
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &whs); err != nil {
+		return nil, fmt.Errorf("selecting webhooks for event[%s]: %w", name, err)
+	}
+
+	return whs, nil
+}
+
+// CreateDelivery queues the delivery. An event that is queued for the same
+// webhook again is ignored, so relaying an event twice sends it once.
+func (s Store) CreateDelivery(ctx context.Context, d Delivery) error {
+
+	// The payload is sent as text, lib/pq would send a []byte as bytea which
+	// the JSONB column does not accept.
+	data := struct {
+		Delivery
+		Payload string `db:"payload"`
+	}{
+		Delivery: d,
+		Payload:  string(d.Payload),
+	}
+
+	const q = `
+	INSERT INTO webhook_deliveries
+		(delivery_id, webhook_id, event_id, event_name, payload, status, attempts, next_attempt, date_created, date_updated)
+	VALUES
+		(:delivery_id, :webhook_id, :event_id, :event_name, :payload, :status, :attempts, :next_attempt, :date_created, :date_updated)
+	ON CONFLICT (webhook_id, event_id) DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("inserting delivery webhookID[%s] eventID[%s]: %w", d.WebhookID, d.EventID, err)
+	}
+
+	return nil
+}
+
+// ClaimDue returns up to limit pending deliveries whose next attempt is due
+// and leases them by moving their next attempt to leaseUntil, all in a single
+// statement. Deliveries claimed by another worker are skipped and a delivery
+// whose outcome is never recorded, because the worker died, is due again once
+// the lease runs out.
+func (s Store) ClaimDue(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]Pending, error) {
+
+	data := struct {
+		Status     string    `db:"status"`
+		Now        time.Time `db:"now"`
+		LeaseUntil time.Time `db:"lease_until"`
+		Limit      int       `db:"limit"`
+	}{
+		Status:     StatusPending,
+		Now:        now,
+		LeaseUntil: leaseUntil,
+		Limit:      limit,
+	}
+
+	const q = `
+	WITH due AS (
+		SELECT
+			delivery_id
+		FROM
+			webhook_deliveries
+		WHERE
+			status = :status AND
+			next_attempt <= :now
+		ORDER BY
+			next_attempt
+		LIMIT :limit
+		FOR UPDATE SKIP LOCKED
+	)
+	UPDATE
+		webhook_deliveries AS d
+	SET
+		"next_attempt" = :lease_until
+	FROM
+		due, webhooks AS w
+	WHERE
+		d.delivery_id = due.delivery_id AND
+		w.webhook_id = d.webhook_id
+	RETURNING
+		d.*, w.url, w.secret`
+
+	var due []Pending
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &due); err != nil {
+		return nil, fmt.Errorf("claiming due deliveries: %w", err)
+	}
+
+	return due, nil
+}
+
+// UpdateDelivery stores the outcome of a delivery attempt.
+func (s Store) UpdateDelivery(ctx context.Context, d Delivery) error {
+
+	const q = `
+	UPDATE
+		webhook_deliveries
+	SET
+		"status" = :status,
+		"attempts" = :attempts,
+		"next_attempt" = :next_attempt,
+		"last_status_code" = :last_status_code,
+		"last_error" = :last_error,
+		"date_updated" = :date_updated
+	WHERE
+		delivery_id = :delivery_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, d); err != nil {
+		return fmt.Errorf("updating delivery deliveryID[%s]: %w", d.ID, err)
+	}
+
+	return nil
+}
+
+// Redeliver queues a delivery to be sent again right away, whatever its
+// current state. The attempts start over.
+func (s Store) Redeliver(ctx context.Context, webhookID string, deliveryID string, now time.Time) error {
+
+	if err := validate.CheckID(webhookID); err != nil {
+		return database.ErrInvalidID
+	}
+	if err := validate.CheckID(deliveryID); err != nil {
+		return database.ErrInvalidID
+	}
+
+	data := struct {
+		WebhookID  string    `db:"webhook_id"`
+		DeliveryID string    `db:"delivery_id"`
+		Status     string    `db:"status"`
+		Now        time.Time `db:"now"`
+	}{
+		WebhookID:  webhookID,
+		DeliveryID: deliveryID,
+		Status:     StatusPending,
+		Now:        now,
+	}
+
+	const q = `
+	UPDATE
+		webhook_deliveries
+	SET
+		"status" = :status,
+		"attempts" = 0,
+		"next_attempt" = :now,
+		"date_updated" = :now
+	WHERE
+		webhook_id = :webhook_id AND
+		delivery_id = :delivery_id
+	RETURNING
+		delivery_id`
+
+	var res struct {
+		DeliveryID string `db:"delivery_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		if err == database.ErrNotFound {
+			return database.ErrNotFound
+		}
+		return fmt.Errorf("redelivering deliveryID[%s]: %w", deliveryID, err)
+	}
+
+	return nil
+}
+
+// QueryDeliveries returns a page of the delivery history of a webhook, newest
+// first, optionally only the deliveries in the specified state.
+func (s Store) QueryDeliveries(ctx context.Context, webhookID string, status string, pageNumber int, rowsPerPage int) ([]Delivery, error) {
+
+	if err := validate.CheckID(webhookID); err != nil {
+		return nil, database.ErrInvalidID
+	}
+
+	data := map[string]interface{}{
+		"webhook_id":    webhookID,
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		webhook_deliveries
+	WHERE
+		webhook_id = :webhook_id`
+
+	buf := bytes.NewBufferString(q)
+	if status != "" {
+		data["status"] = status
+		buf.WriteString(` AND
+		status = :status`)
+	}
+	buf.WriteString(`
+	ORDER BY
+		date_created DESC, delivery_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`)
+
+	var dls []Delivery
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dls); err != nil {
+		return nil, fmt.Errorf("selecting deliveries webhookID[%s]: %w", webhookID, err)
+	}
+
+	return dls, nil
+}
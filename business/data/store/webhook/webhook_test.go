@@ -0,0 +1,111 @@
+package webhook_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/data/store/webhook"
+	"github.com/Avyukth/service3-clone/business/data/tests"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+)
+
+var dbc = tests.DBContainer{
+	Image: "postgres:latest",
+	Port:  "5432",
+	Args:  []string{"-e", "POSTGRES_PASSWORD=postgres"},
+}
+
+func TestWebhook(t *testing.T) {
+
+	log, db, teardown := tests.NewUnit(t, dbc)
+
+	t.Cleanup(teardown)
+
+	store := webhook.NewStore(log, db)
+
+	t.Log("Given the need to deliver events to webhooks.")
+	{
+		testID := 0
+
+		t.Logf("\t Test %d:\tWhen queueing an event for a subscribed webhook.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+			nw := webhook.NewWebhook{
+				URL:    "https://example.com/hooks",
+				Events: []string{"user.created"},
+			}
+
+			wh, err := store.Create(ctx, nw, "whsec_test", now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a webhook : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a webhook.", tests.Success, testID)
+
+			whs, err := store.QueryByEvent(ctx, "user.created")
+			if err != nil || len(whs) != 1 || whs[0].ID != wh.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould find the webhook by its event : %v, %v.", tests.Failed, testID, whs, err)
+			}
+			if whs, _ := store.QueryByEvent(ctx, "sale.created"); len(whs) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould not find the webhook for other events : %v.", tests.Failed, testID, whs)
+			}
+			t.Logf("\t%s\tTest %d:\tShould find the webhook by its event only.", tests.Success, testID)
+
+			d := webhook.Delivery{
+				ID:          validate.GenerateID(),
+				WebhookID:   wh.ID,
+				EventID:     validate.GenerateID(),
+				EventName:   "user.created",
+				Payload:     []byte(`{"name":"user.created"}`),
+				Status:      webhook.StatusPending,
+				NextAttempt: now,
+				DateCreated: now,
+				DateUpdated: now,
+			}
+			if err := store.CreateDelivery(ctx, d); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to queue a delivery : %s.", tests.Failed, testID, err)
+			}
+			dup := d
+			dup.ID = validate.GenerateID()
+			if err := store.CreateDelivery(ctx, dup); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould ignore a queued event : %s.", tests.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to queue a delivery once.", tests.Success, testID)
+
+			due, err := store.ClaimDue(ctx, now, now.Add(time.Minute), 10)
+			if err != nil || len(due) != 1 || due[0].URL != nw.URL {
+				t.Fatalf("\t%s\tTest %d:\tShould claim the due delivery : %v, %v.", tests.Failed, testID, due, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould claim the due delivery.", tests.Success, testID)
+
+			due, err = store.ClaimDue(ctx, now, now.Add(time.Minute), 10)
+			if err != nil || len(due) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould not claim a leased delivery again : %v, %v.", tests.Failed, testID, due, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not claim a leased delivery again.", tests.Success, testID)
+
+			d.Status = webhook.StatusDead
+			d.Attempts = 10
+			if err := store.UpdateDelivery(ctx, d); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to update the delivery : %s.", tests.Failed, testID, err)
+			}
+
+			dls, err := store.QueryDeliveries(ctx, wh.ID, webhook.StatusDead, 1, 10)
+			if err != nil || len(dls) != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould find the dead delivery in the history : %v, %v.", tests.Failed, testID, dls, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould find the dead delivery in the history.", tests.Success, testID)
+
+			if err := store.Redeliver(ctx, wh.ID, d.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to redeliver : %s.", tests.Failed, testID, err)
+			}
+			dls, err = store.QueryDeliveries(ctx, wh.ID, webhook.StatusPending, 1, 10)
+			if err != nil || len(dls) != 1 || dls[0].Attempts != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould queue the delivery again : %v, %v.", tests.Failed, testID, dls, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould queue the delivery again.", tests.Success, testID)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package tests
 import (
 	"bytes"
 	"context"
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/Avyukth/service3-clone/business/data/schema"
+	"github.com/Avyukth/service3-clone/business/data/store/role"
 	"github.com/Avyukth/service3-clone/business/data/store/user"
 	"github.com/Avyukth/service3-clone/business/sys/auth"
 	"github.com/Avyukth/service3-clone/business/sys/database"
@@ -113,7 +115,7 @@ func NewIntegration(t *testing.T, dbc DBContainer) *Test {
 		t.Fatalf("Generating private key: %s", err)
 	}
 
-	auth, err := auth.New(keyID, keystore.NewMap(map[string]*rsa.PrivateKey{keyID: privateKey}))
+	auth, err := auth.New(keyID, keystore.NewMap(map[string]crypto.Signer{keyID: privateKey}))
 	if err != nil {
 		t.Fatalf("Auth error: %s", err)
 	}
@@ -145,6 +147,18 @@ func (test *Test) Token(email, pass string) string {
 	return token
 }
 
+// Grant resolves the permissions the roles of the claims are granted, as
+// authenticating a request does.
+func Grant(t *testing.T, log *zap.SugaredLogger, db *sqlx.DB, claims auth.Claims) auth.Claims {
+	granted, err := role.NewStore(log, db).Permissions(context.Background(), claims.Roles)
+	if err != nil {
+		t.Fatalf("Resolving permissions error: %s", err)
+	}
+	claims.Granted = granted
+
+	return claims
+}
+
 func StringPointer(s string) *string {
 	return &s
 }
@@ -153,6 +167,10 @@ func IntPointer(i int) *int {
 	return &i
 }
 
+func BoolPointer(b bool) *bool {
+	return &b
+}
+
 // May be removed in the future
 func deleteDB(t *testing.T, ctx context.Context, db *sqlx.DB) error {
 	if err := schema.Seed(ctx, db); err != nil {
@@ -1,36 +1,71 @@
 package auth
 
 import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// private and public keys for JWT use
+// private and public keys for JWT use. Keys can be RSA (RS256), ECDSA (ES256,
+// ES384, ES512 depending on the curve) or Ed25519 (EdDSA).
 type KeyLookup interface {
-	PrivateKey(kid string) (*rsa.PrivateKey, error)
-	PublicKey(kid string) (*rsa.PublicKey, error)
+	PrivateKey(kid string) (crypto.PrivateKey, error)
+	PublicKey(kid string) (crypto.PublicKey, error)
+	PublicKeys() (map[string]crypto.PublicKey, error)
 }
 
+// Revoker reports whether a token, identified by its id (jti), has been
+// revoked before its expiry.
+type Revoker interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// Algorithms lists the JWT signing algorithms tokens can be signed with.
+var Algorithms = []string{
+	jwt.SigningMethodRS256.Alg(),
+	jwt.SigningMethodES256.Alg(),
+	jwt.SigningMethodES384.Alg(),
+	jwt.SigningMethodES512.Alg(),
+	jwt.SigningMethodEdDSA.Alg(),
+}
+
+// APIKeyResolver returns the claims of the user an API key was issued to,
+// narrowed to what the key was scoped to. Keys that are unknown, expired or
+// revoked return ErrInvalidAPIKey.
+type APIKeyResolver interface {
+	ResolveAPIKey(ctx context.Context, key string) (Claims, error)
+}
+
+// ErrInvalidAPIKey is returned for API keys that can not be used.
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
 // Auth is to used for authenticate clients
 type Auth struct {
+	mu        sync.RWMutex
 	activeKID string
 	ketLookup KeyLookup
-	method    jwt.SigningMethod
 	keyFunc   func(t *jwt.Token) (interface{}, error)
 	parser    jwt.Parser
+	revoker   Revoker
+	resolver  PermissionResolver
+	apiKeys   APIKeyResolver
 }
 
 func New(activeKID string, keyLookup KeyLookup) (*Auth, error) {
-	_, err := keyLookup.PrivateKey(activeKID)
+	privateKey, err := keyLookup.PrivateKey(activeKID)
 	if err != nil {
 		return nil, errors.New("active key does not exist in store")
 	}
-	method := jwt.GetSigningMethod("RS256")
-	if method == nil {
-		return nil, errors.New("unsupported signing method RS256")
+	if _, err := signingMethod(privateKey); err != nil {
+		return nil, fmt.Errorf("active key: %w", err)
 	}
 
 	keyFunc := func(t *jwt.Token) (interface{}, error) {
@@ -45,29 +80,80 @@ func New(activeKID string, keyLookup KeyLookup) (*Auth, error) {
 		return keyLookup.PublicKey(kidID)
 	}
 
-	parser := jwt.Parser{}
+	parser := jwt.NewParser(jwt.WithValidMethods(Algorithms))
 
 	a := Auth{
 		activeKID: activeKID,
 		ketLookup: keyLookup,
-		method:    method,
 		keyFunc:   keyFunc,
-		parser:    parser,
+		parser:    *parser,
 	}
 
 	return &a, nil
 
 }
 
+// SetActiveKID switches the key used to sign new tokens. Tokens signed with the
+// previous key keep validating for as long as the key store knows that key.
+func (a *Auth) SetActiveKID(kid string) error {
+	privateKey, err := a.ketLookup.PrivateKey(kid)
+	if err != nil {
+		return fmt.Errorf("active key %s does not exist in store", kid)
+	}
+	if _, err := signingMethod(privateKey); err != nil {
+		return fmt.Errorf("active key %s: %w", kid, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.activeKID = kid
+
+	return nil
+}
+
+// ActiveKID returns the id of the key currently used to sign tokens.
+func (a *Auth) ActiveKID() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.activeKID
+}
+
+// Values of the typ header. Access tokens are typed as in RFC 9068 so an ID
+// token, which is signed with the same keys, is never taken for one.
+const (
+	typeAccessToken = "at+jwt"
+	typeIDToken     = "JWT"
+)
+
+// GenerateToken signs the claims as an access token.
 func (a *Auth) GenerateToken(claims Claims) (string, error) {
-	token := jwt.NewWithClaims(a.method, claims)
-	token.Header["kid"] = a.activeKID
+	return a.sign(claims, typeAccessToken)
+}
+
+// GenerateIDToken signs the claims as an OpenID Connect ID token. ID tokens are
+// not accepted by ValidateToken.
+func (a *Auth) GenerateIDToken(claims IDClaims) (string, error) {
+	return a.sign(claims, typeIDToken)
+}
 
-	privateKey, err := a.ketLookup.PrivateKey(a.activeKID)
+// sign signs the claims with the active key, setting the kid and typ headers.
+func (a *Auth) sign(claims jwt.Claims, typ string) (string, error) {
+	activeKID := a.ActiveKID()
+
+	privateKey, err := a.ketLookup.PrivateKey(activeKID)
 	if err != nil {
 		return "", errors.New("kid lookup Failed")
 	}
 
+	method, err := signingMethod(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = activeKID
+	token.Header["typ"] = typ
+
 	tokenSString, err := token.SignedString(privateKey)
 	if err != nil {
 		return "", fmt.Errorf("token signing Failed: %v", err)
@@ -75,6 +161,7 @@ func (a *Auth) GenerateToken(claims Claims) (string, error) {
 	return tokenSString, nil
 }
 
+// ValidateToken returns the claims of an access token.
 func (a *Auth) ValidateToken(tokenString string) (Claims, error) {
 	var claims Claims
 	token, err := a.parser.ParseWithClaims(tokenString, &claims, a.keyFunc)
@@ -86,5 +173,128 @@ func (a *Auth) ValidateToken(tokenString string) (Claims, error) {
 	if !token.Valid {
 		return claims, errors.New("token is not valid")
 	}
+
+	if typ, _ := token.Header["typ"].(string); typ != typeAccessToken {
+		return Claims{}, errors.New("token is not an access token")
+	}
+	return claims, nil
+}
+
+// SetRevoker sets the deny list consulted by IsRevoked. It must be called
+// before the Auth value is used to serve requests.
+func (a *Auth) SetRevoker(revoker Revoker) {
+	a.revoker = revoker
+}
+
+// IsRevoked reports whether the token with the specified id has been revoked.
+// Without a Revoker no token is ever considered revoked.
+func (a *Auth) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	if a.revoker == nil {
+		return false, nil
+	}
+	return a.revoker.IsRevoked(ctx, jti)
+}
+
+// SetPermissionResolver sets where HasPermissions looks up the permissions of
+// a role. It must be called before the Auth value is used to serve requests.
+func (a *Auth) SetPermissionResolver(resolver PermissionResolver) {
+	a.resolver = resolver
+}
+
+// SetAPIKeyResolver sets where ValidateAPIKey looks up API keys. It must be
+// called before the Auth value is used to serve requests.
+func (a *Auth) SetAPIKeyResolver(apiKeys APIKeyResolver) {
+	a.apiKeys = apiKeys
+}
+
+// ValidateAPIKey returns the claims the API key stands for. Without a resolver
+// no key is accepted.
+func (a *Auth) ValidateAPIKey(ctx context.Context, key string) (Claims, error) {
+	if a.apiKeys == nil {
+		return Claims{}, ErrInvalidAPIKey
+	}
+	return a.apiKeys.ResolveAPIKey(ctx, key)
+}
+
+// Grant resolves the permissions the roles in the claims grant into
+// claims.Granted. Without a resolver only admins are granted, and then every
+// permission.
+func (a *Auth) Grant(ctx context.Context, claims Claims) (Claims, error) {
+	if a.resolver == nil {
+		claims.Granted = nil
+		if claims.Authorized(RoleAdmin) {
+			claims.Granted = Permissions
+		}
+		return claims, nil
+	}
+
+	granted, err := a.resolver.Permissions(ctx, claims.Roles)
+	if err != nil {
+		return Claims{}, err
+	}
+	claims.Granted = granted
+
 	return claims, nil
 }
+
+// HasPermissions reports whether the roles in the claims together grant every
+// one of the specified permissions. Claims that list permissions, as those of
+// a scoped API key do, must also list every one of them. Without a resolver
+// only admins do.
+func (a *Auth) HasPermissions(ctx context.Context, claims Claims, perms ...Permission) (bool, error) {
+	if len(claims.Permissions) > 0 && !contains(claims.Permissions, perms) {
+		return false, nil
+	}
+
+	if a.resolver == nil {
+		return claims.Authorized(RoleAdmin), nil
+	}
+
+	granted, err := a.resolver.Permissions(ctx, claims.Roles)
+	if err != nil {
+		return false, err
+	}
+
+	return contains(granted, perms), nil
+}
+
+// contains reports whether every one of the wanted permissions is granted.
+func contains(granted []Permission, wanted []Permission) bool {
+	for _, want := range wanted {
+		found := false
+		for _, has := range granted {
+			if has == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// signingMethod picks the JWT signing method matching the type of private key.
+func signingMethod(privateKey crypto.PrivateKey) (jwt.SigningMethod, error) {
+	switch pk := privateKey.(type) {
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256, nil
+
+	case *ecdsa.PrivateKey:
+		switch pk.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+		return nil, fmt.Errorf("unsupported ecdsa curve %s", pk.Curve.Params().Name)
+
+	case ed25519.PrivateKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+
+	return nil, fmt.Errorf("unsupported private key type %T", privateKey)
+}
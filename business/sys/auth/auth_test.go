@@ -1,6 +1,10 @@
 package auth_test
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"testing"
@@ -28,13 +32,13 @@ func TestAuth(t *testing.T) {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to generate private key: %v", failed, testID, err)
 			}
 			t.Logf("\t%s\tTest %d:\tShould be able to generate private key.", success, testID)
-			a, err := auth.New(KeyID, &keyStore{pk: privateKey})
+			a, err := auth.New(KeyID, &keyStore{kid: KeyID, pk: privateKey})
 			if err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to create authenticator: %v", failed, testID, err)
 			}
 			t.Logf("\t%s\tTest %d:\tShould be able to create authenticator.", success, testID)
 			claims := auth.Claims{
-				jwt.RegisteredClaims{
+				RegisteredClaims: jwt.RegisteredClaims{
 					// A usual scenario is to set the expiration time relative to the current time
 					ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
 					IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -44,7 +48,7 @@ func TestAuth(t *testing.T) {
 					ID:        "1",
 					Audience:  []string{"somebody_else"},
 				},
-				[]auth.Role{auth.RoleAdmin},
+				Roles: []auth.Role{auth.RoleAdmin},
 			}
 			token, err := a.GenerateToken(claims)
 			if err != nil {
@@ -71,18 +75,119 @@ func TestAuth(t *testing.T) {
 				t.Fatalf("\t%s\tTest %d:\tShould have the expected role: %v", failed, testID, err)
 			}
 			t.Logf("\t%s\tTest %d:\tShould have the expected role.", success, testID)
+
+			idClaims := auth.IDClaims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+					IssuedAt:  jwt.NewNumericDate(time.Now()),
+					Subject:   "123456789",
+					Audience:  []string{"client"},
+				},
+				Name: "Bill Kennedy",
+			}
+			idToken, err := a.GenerateIDToken(idClaims)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to generate an ID token: %v", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to generate an ID token.", success, testID)
+
+			if _, err := a.ValidateToken(idToken); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould not accept an ID token as an access token.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not accept an ID token as an access token.", success, testID)
+		}
+	}
+}
+
+func TestAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Should be able to generate rsa key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate ecdsa key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate ed25519 key: %v", err)
+	}
+
+	tt := []struct {
+		alg string
+		kty string
+		pk  crypto.Signer
+	}{
+		{alg: "RS256", kty: "RSA", pk: rsaKey},
+		{alg: "ES256", kty: "EC", pk: ecKey},
+		{alg: "EdDSA", kty: "OKP", pk: edKey},
+	}
+
+	t.Log("Given the need to sign tokens with different key types.")
+	{
+		for testID, tst := range tt {
+			t.Logf("\tTest %d:\tWhen using a %s key.", testID, tst.alg)
+			{
+				const KeyID = "54bb2165-71e1-41a6-af3e-7da4a0e1e2c1"
+				a, err := auth.New(KeyID, &keyStore{kid: KeyID, pk: tst.pk})
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to create authenticator: %v", failed, testID, err)
+				}
+
+				claims := auth.Claims{
+					RegisteredClaims: jwt.RegisteredClaims{
+						ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+						IssuedAt:  jwt.NewNumericDate(time.Now()),
+						Subject:   "123456789",
+					},
+					Roles: []auth.Role{auth.RoleUser},
+				}
+
+				token, err := a.GenerateToken(claims)
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to generate JWT: %v", failed, testID, err)
+				}
+
+				parsed, _, err := jwt.NewParser().ParseUnverified(token, &auth.Claims{})
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to decode the JWT: %v", failed, testID, err)
+				}
+				if got := parsed.Method.Alg(); got != tst.alg {
+					t.Fatalf("\t%s\tTest %d:\tShould sign with %s, got %s.", failed, testID, tst.alg, got)
+				}
+				t.Logf("\t%s\tTest %d:\tShould sign with %s.", success, testID, tst.alg)
+
+				if _, err := a.ValidateToken(token); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to validate the JWT: %v", failed, testID, err)
+				}
+				t.Logf("\t%s\tTest %d:\tShould be able to validate the JWT.", success, testID)
+
+				set, err := a.JWKS()
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to build the JWKS: %v", failed, testID, err)
+				}
+				if len(set.Keys) != 1 || set.Keys[0].KeyID != KeyID || set.Keys[0].KeyType != tst.kty || set.Keys[0].Algorithm != tst.alg {
+					t.Fatalf("\t%s\tTest %d:\tShould publish the public key: %+v", failed, testID, set)
+				}
+				t.Logf("\t%s\tTest %d:\tShould publish the public key.", success, testID)
+			}
 		}
 	}
 }
 
 type keyStore struct {
-	pk *rsa.PrivateKey
+	kid string
+	pk  crypto.Signer
 }
 
-func (ks *keyStore) PrivateKey(kid string) (*rsa.PrivateKey, error) {
+func (ks *keyStore) PrivateKey(kid string) (crypto.PrivateKey, error) {
 	return ks.pk, nil
 }
 
-func (ks *keyStore) PublicKey(kid string) (*rsa.PublicKey, error) {
-	return &ks.pk.PublicKey, nil
+func (ks *keyStore) PublicKey(kid string) (crypto.PublicKey, error) {
+	return ks.pk.Public(), nil
+}
+
+func (ks *keyStore) PublicKeys() (map[string]crypto.PublicKey, error) {
+	return map[string]crypto.PublicKey{ks.kid: ks.pk.Public()}, nil
 }
@@ -7,8 +7,28 @@ import (
 )
 
 type Claims struct {
-	jwt.RegisteredClaims `json:"registered"`
-	Roles                []Role `json:"roles"`
+	jwt.RegisteredClaims
+	Roles       []Role       `json:"roles"`
+	TenantID    string       `json:"tenant_id,omitempty"`
+	Permissions []Permission `json:"permissions,omitempty"`
+
+	// APIKey is set when the claims stand for an API key rather than a token.
+	// It is never part of a token.
+	APIKey bool `json:"-"`
+
+	// Granted holds the permissions the roles grant. It is resolved when the
+	// request is authenticated and is never part of a token.
+	Granted []Permission `json:"-"`
+}
+
+// IDClaims are the claims of an OpenID Connect ID token. They tell a client who
+// signed in and grant nothing, the profile claims are only set for the scopes
+// the client asked for.
+type IDClaims struct {
+	jwt.RegisteredClaims
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
 }
 
 func (c Claims) Authorized(roles ...Role) bool {
@@ -22,6 +42,16 @@ func (c Claims) Authorized(roles ...Role) bool {
 	return false
 }
 
+// Permitted reports whether the roles grant the permission.
+func (c Claims) Permitted(perm Permission) bool {
+	for _, has := range c.Granted {
+		if has == perm {
+			return true
+		}
+	}
+	return false
+}
+
 type ctxKey int
 
 const key ctxKey = 1
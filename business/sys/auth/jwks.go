@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// JWK is a single public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set as published on /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns every public key known to the key store so other services can
+// verify tokens without access to the private keys.
+func (a *Auth) JWKS() (JWKS, error) {
+	publicKeys, err := a.ketLookup.PublicKeys()
+	if err != nil {
+		return JWKS{}, fmt.Errorf("listing public keys: %w", err)
+	}
+
+	kids := make([]string, 0, len(publicKeys))
+	for kid := range publicKeys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := JWKS{
+		Keys: make([]JWK, 0, len(kids)),
+	}
+	for _, kid := range kids {
+		jwk, err := NewJWK(kid, publicKeys[kid])
+		if err != nil {
+			return JWKS{}, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set, nil
+}
+
+// NewJWK converts a public key into its JWK representation.
+func NewJWK(kid string, publicKey crypto.PublicKey) (JWK, error) {
+	enc := base64.RawURLEncoding
+
+	switch pk := publicKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType:   "RSA",
+			KeyID:     kid,
+			Use:       "sig",
+			Algorithm: "RS256",
+			N:         enc.EncodeToString(pk.N.Bytes()),
+			E:         enc.EncodeToString(big.NewInt(int64(pk.E)).Bytes()),
+		}, nil
+
+	case *ecdsa.PublicKey:
+		params := pk.Curve.Params()
+		size := (params.BitSize + 7) / 8
+
+		var alg string
+		switch params.Name {
+		case "P-256":
+			alg = "ES256"
+		case "P-384":
+			alg = "ES384"
+		case "P-521":
+			alg = "ES512"
+		default:
+			return JWK{}, fmt.Errorf("unsupported ecdsa curve %s for kid %s", params.Name, kid)
+		}
+
+		return JWK{
+			KeyType:   "EC",
+			KeyID:     kid,
+			Use:       "sig",
+			Algorithm: alg,
+			Curve:     params.Name,
+			X:         enc.EncodeToString(pk.X.FillBytes(make([]byte, size))),
+			Y:         enc.EncodeToString(pk.Y.FillBytes(make([]byte, size))),
+		}, nil
+
+	case ed25519.PublicKey:
+		return JWK{
+			KeyType:   "OKP",
+			KeyID:     kid,
+			Use:       "sig",
+			Algorithm: "EdDSA",
+			Curve:     "Ed25519",
+			X:         enc.EncodeToString(pk),
+		}, nil
+	}
+
+	return JWK{}, fmt.Errorf("unsupported public key type %T for kid %s", publicKey, kid)
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+// Set of permissions the service checks. Roles are granted any number of them.
+var (
+	PermUsersRead     = Permission{"users:read"}
+	PermUsersWrite    = Permission{"users:write"}
+	PermProductsRead  = Permission{"products:read"}
+	PermProductsWrite = Permission{"products:write"}
+	PermSalesRead     = Permission{"sales:read"}
+	PermSalesWrite    = Permission{"sales:write"}
+	PermRolesRead     = Permission{"roles:read"}
+	PermRolesWrite    = Permission{"roles:write"}
+	PermAuditRead     = Permission{"audit:read"}
+	PermWebhooksRead  = Permission{"webhooks:read"}
+	PermWebhooksWrite = Permission{"webhooks:write"}
+)
+
+// Permissions lists every permission in the order they are declared.
+var Permissions = []Permission{
+	PermUsersRead, PermUsersWrite,
+	PermProductsRead, PermProductsWrite,
+	PermSalesRead, PermSalesWrite,
+	PermRolesRead, PermRolesWrite,
+	PermAuditRead,
+	PermWebhooksRead, PermWebhooksWrite,
+}
+
+type Permission struct {
+	name string
+}
+
+func ParsePermission(value string) (Permission, error) {
+	for _, p := range Permissions {
+		if p.name == value {
+			return p, nil
+		}
+	}
+
+	return Permission{}, errors.New("invalid permission")
+}
+
+func (p Permission) Name() string {
+	return p.name
+}
+
+func (p *Permission) UnmarshalText(data []byte) error {
+	perm, err := ParsePermission(string(data))
+	if err != nil {
+		return err
+	}
+	*p = perm
+	return nil
+}
+
+func (p Permission) MarshalText() ([]byte, error) {
+	return []byte(p.name), nil
+}
+
+// PermissionResolver returns the permissions granted by a set of roles.
+type PermissionResolver interface {
+	Permissions(ctx context.Context, roles []Role) ([]Permission, error)
+}
@@ -0,0 +1,94 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+)
+
+type resolver map[auth.Role][]auth.Permission
+
+func (r resolver) Permissions(ctx context.Context, roles []auth.Role) ([]auth.Permission, error) {
+	var perms []auth.Permission
+	for _, rl := range roles {
+		perms = append(perms, r[rl]...)
+	}
+	return perms, nil
+}
+
+func TestPermissions(t *testing.T) {
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate a private key: %v", err)
+	}
+
+	a, err := auth.New("kid", &keyStore{kid: "kid", pk: pk})
+	if err != nil {
+		t.Fatalf("Should be able to create an authenticator: %v", err)
+	}
+
+	editor := auth.MustParseRole("EDITOR")
+	a.SetPermissionResolver(resolver{
+		editor:        {auth.PermProductsRead, auth.PermProductsWrite},
+		auth.RoleUser: {auth.PermSalesRead},
+	})
+
+	tt := []struct {
+		name  string
+		roles []auth.Role
+		scope []auth.Permission
+		perms []auth.Permission
+		exp   bool
+	}{
+		{"one role grants the permission", []auth.Role{editor}, nil, []auth.Permission{auth.PermProductsWrite}, true},
+		{"roles grant the permissions together", []auth.Role{editor, auth.RoleUser}, nil, []auth.Permission{auth.PermProductsRead, auth.PermSalesRead}, true},
+		{"one permission is missing", []auth.Role{editor}, nil, []auth.Permission{auth.PermProductsRead, auth.PermSalesRead}, false},
+		{"the role grants nothing", []auth.Role{auth.RoleAdmin}, nil, []auth.Permission{auth.PermUsersRead}, false},
+		{"the claims are scoped to the permission", []auth.Role{editor}, []auth.Permission{auth.PermProductsRead}, []auth.Permission{auth.PermProductsRead}, true},
+		{"the claims are scoped to other permissions", []auth.Role{editor}, []auth.Permission{auth.PermProductsRead}, []auth.Permission{auth.PermProductsWrite}, false},
+	}
+
+	t.Log("Given the need to authorize requests by permission.")
+	{
+		for testID, tst := range tt {
+			t.Logf("\tTest %d:\tWhen %s.", testID, tst.name)
+			{
+				got, err := a.HasPermissions(context.Background(), auth.Claims{Roles: tst.roles, Permissions: tst.scope}, tst.perms...)
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to check the permissions: %v", failed, testID, err)
+				}
+				if got != tst.exp {
+					t.Fatalf("\t%s\tTest %d:\tShould report %t, got %t.", failed, testID, tst.exp, got)
+				}
+				t.Logf("\t%s\tTest %d:\tShould report %t.", success, testID, tst.exp)
+			}
+		}
+	}
+
+	t.Log("Given the need to parse role and permission names.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen parsing names.", testID)
+		{
+			if _, err := auth.ParseRole("sales-manager"); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould reject a malformed role name.", failed, testID)
+			}
+			if _, err := auth.ParseRole("SALES_MANAGER"); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould accept a well formed role name: %v", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould only accept well formed role names.", success, testID)
+
+			if _, err := auth.ParsePermission("users:delete"); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould reject an unknown permission.", failed, testID)
+			}
+			if p, err := auth.ParsePermission("users:write"); err != nil || p != auth.PermUsersWrite {
+				t.Fatalf("\t%s\tTest %d:\tShould parse a known permission: %v", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould only accept known permissions.", success, testID)
+		}
+	}
+}
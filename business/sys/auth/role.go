@@ -1,29 +1,37 @@
 package auth
 
 import (
+	"database/sql/driver"
 	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/lib/pq"
 )
 
+// Built-in roles. They always exist, other roles are managed at runtime and
+// stored in the database.
 var (
-	RoleAdmin = Role{"ADMIN"}
-	RoleUser  = Role{"USER"}
+	RoleSuperAdmin = Role{"SUPER_ADMIN"}
+	RoleAdmin      = Role{"ADMIN"}
+	RoleUser       = Role{"USER"}
 )
 
-var roles = map[string]Role{RoleAdmin.name: RoleUser, RoleUser.name: RoleUser}
+// roleName is the form every role name must have.
+var roleName = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,31}$`)
 
 type Role struct {
 	name string
 }
 
+// ParseRole checks the value is a well formed role name. Whether a role with
+// that name exists is up to the role store.
 func ParseRole(value string) (Role, error) {
-
-	role, exists := roles[value]
-	if !exists {
-
+	if !roleName.MatchString(value) {
 		return Role{}, errors.New("invalid role")
 	}
 
-	return role, nil
+	return Role{value}, nil
 }
 
 func MustParseRole(value string) Role {
@@ -34,19 +42,60 @@ func MustParseRole(value string) Role {
 	return role
 }
 
+// BuiltIn reports whether the role is one of the roles the service relies on.
+func (r Role) BuiltIn() bool {
+	return r == RoleSuperAdmin || r == RoleAdmin || r == RoleUser
+}
+
 func (r Role) Name() string {
 	return r.name
 }
 
+// UnmarshalText only accepts well formed role names.
 func (r *Role) UnmarshalText(data []byte) error {
-	r.name = string(data)
+	role, err := ParseRole(string(data))
+	if err != nil {
+		return err
+	}
+	*r = role
 	return nil
 }
 
-func (r *Role) MarshalText() ([]byte, error) {
+func (r Role) MarshalText() ([]byte, error) {
 	return []byte(r.name), nil
 }
 
 func (r Role) Equal(other Role) bool {
 	return r.name == other.name
 }
+
+// Roles is a list of roles kept in a TEXT[] column. Scanning parses every
+// name, so a malformed role in the database is an error rather than a role.
+type Roles []Role
+
+func (rs *Roles) Scan(src any) error {
+	var names pq.StringArray
+	if err := names.Scan(src); err != nil {
+		return err
+	}
+
+	roles := make(Roles, len(names))
+	for i, name := range names {
+		role, err := ParseRole(name)
+		if err != nil {
+			return fmt.Errorf("scanning role %q: %w", name, err)
+		}
+		roles[i] = role
+	}
+	*rs = roles
+
+	return nil
+}
+
+func (rs Roles) Value() (driver.Value, error) {
+	names := make(pq.StringArray, len(rs))
+	for i, role := range rs {
+		names[i] = role.name
+	}
+	return names.Value()
+}
@@ -0,0 +1,49 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+)
+
+func TestRoles(t *testing.T) {
+	t.Log("Given the need to read roles from requests and the database.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen decoding roles from JSON.", testID)
+		{
+			var roles []auth.Role
+			if err := json.Unmarshal([]byte(`["ADMIN","USER"]`), &roles); err != nil || len(roles) != 2 || roles[0] != auth.RoleAdmin {
+				t.Fatalf("\t%s\tTest %d:\tShould decode well formed roles: %v, %v", failed, testID, roles, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould decode well formed roles.", success, testID)
+
+			if err := json.Unmarshal([]byte(`["admin"]`), &roles); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould not decode a malformed role.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not decode a malformed role.", success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen scanning roles from a TEXT[] column.", testID)
+		{
+			in := auth.Roles{auth.RoleAdmin, auth.RoleUser}
+			v, err := in.Value()
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to store the roles: %v", failed, testID, err)
+			}
+
+			var out auth.Roles
+			if err := out.Scan([]byte(v.(string))); err != nil || len(out) != 2 || out[1] != auth.RoleUser {
+				t.Fatalf("\t%s\tTest %d:\tShould scan back the stored roles: %v, %v", failed, testID, out, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould scan back the stored roles.", success, testID)
+
+			if err := out.Scan([]byte(`{admin}`)); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould not scan a malformed role.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not scan a malformed role.", success, testID)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"net/url"
@@ -23,6 +24,8 @@ var (
 	ErrAuthenticationFailure = errors.New("authentication failed")
 	ErrForbidden             = errors.New("attempt action not allowed")
 	ErrInvalidEmail          = errors.New("invalid email")
+	ErrInvalidCursor         = errors.New("cursor is not in proper form")
+	ErrVersionConflict       = errors.New("record was changed since it was read")
 )
 
 type Config struct {
@@ -98,7 +101,87 @@ func StatusCheck(ctx context.Context, db *sqlx.DB) error {
 	return db.QueryRowContext(ctx, q).Scan(&tmp)
 }
 
-func NamedExecContext(ctx context.Context, log *zap.SugaredLogger, db *sqlx.DB, query string, data any) error {
+// =============================================================================
+
+type ctxKey int
+
+const txKey ctxKey = 1
+
+// WithinTran runs fn inside a database transaction. The transaction is carried
+// in the context handed to fn so every helper in this package called with that
+// context joins it. The transaction is rolled back when fn returns an error or
+// panics and committed otherwise. Nested calls reuse the outer transaction.
+func WithinTran(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tran: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+
+		if err != nil {
+			if errTx := tx.Rollback(); errTx != nil && !errors.Is(errTx, sql.ErrTxDone) {
+				err = fmt.Errorf("rollback tran: %v: %w", errTx, err)
+			}
+			return
+		}
+
+		if errTx := tx.Commit(); errTx != nil {
+			err = fmt.Errorf("commit tran: %w", errTx)
+		}
+	}()
+
+	return fn(context.WithValue(ctx, txKey, tx))
+}
+
+// WithinSavepoint runs fn inside a savepoint of the transaction carried in the
+// context. When fn returns an error its work is rolled back to the savepoint,
+// which keeps the transaction usable, and the error is returned. Without a
+// transaction in the context it behaves like WithinTran.
+func WithinSavepoint(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) error {
+	tx, ok := ctx.Value(txKey).(*sqlx.Tx)
+	if !ok {
+		return WithinTran(ctx, db, fn)
+	}
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT within_savepoint"); err != nil {
+		return fmt.Errorf("savepoint: %w", err)
+	}
+
+	if err := fn(ctx); err != nil {
+		if _, errSp := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT within_savepoint"); errSp != nil {
+			return fmt.Errorf("rollback to savepoint: %v: %w", errSp, err)
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT within_savepoint"); err != nil {
+		return fmt.Errorf("release savepoint: %w", err)
+	}
+
+	return nil
+}
+
+// executor returns the transaction stored in the context when there is one,
+// otherwise the provided database handle.
+func executor(ctx context.Context, db sqlx.ExtContext) sqlx.ExtContext {
+	if tx, ok := ctx.Value(txKey).(*sqlx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// =============================================================================
+
+func NamedExecContext(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtContext, query string, data any) error {
 
 	q := queryString(query, data)
 	log.Infow("database.NameExecContext", "traceid", web.GetTraceID(ctx), "query", q)
@@ -106,13 +189,13 @@ func NamedExecContext(ctx context.Context, log *zap.SugaredLogger, db *sqlx.DB,
 	ctx, span := otel.GetTracerProvider().Tracer("").Start(ctx, "database.query")
 	span.SetAttributes(attribute.String("query", q))
 	defer span.End()
-	if _, err := db.NamedExecContext(ctx, q, data); err != nil {
+	if _, err := sqlx.NamedExecContext(ctx, executor(ctx, db), query, data); err != nil {
 		return err
 	}
 	return nil
 }
 
-func NamedQuerySlice(ctx context.Context, log *zap.SugaredLogger, db *sqlx.DB, query string, data interface{}, dest any) error {
+func NamedQuerySlice(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtContext, query string, data interface{}, dest any) error {
 
 	q := queryString(query, data)
 	log.Infow("database.NamedQuerySlice", "traceid", web.GetTraceID(ctx), "query", q)
@@ -126,10 +209,12 @@ func NamedQuerySlice(ctx context.Context, log *zap.SugaredLogger, db *sqlx.DB, q
 		return errors.New("must provide a pointer to a slice")
 	}
 
-	rows, err := db.NamedQueryContext(ctx, query, data)
+	rows, err := sqlx.NamedQueryContext(ctx, executor(ctx, db), query, data)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
+
 	slice := val.Elem()
 	for rows.Next() {
 		v := reflect.New(slice.Type().Elem())
@@ -138,10 +223,10 @@ func NamedQuerySlice(ctx context.Context, log *zap.SugaredLogger, db *sqlx.DB, q
 		}
 		slice.Set(reflect.Append(slice, v.Elem()))
 	}
-	return nil
+	return rows.Err()
 }
 
-func NamedQueryStruct(ctx context.Context, log *zap.SugaredLogger, db *sqlx.DB, query string, data interface{}, dest any) error {
+func NamedQueryStruct(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtContext, query string, data interface{}, dest any) error {
 
 	q := queryString(query, data)
 	log.Infow("database.NamedQueryStruct", "traceid", web.GetTraceID(ctx), "query", q)
@@ -149,13 +234,17 @@ func NamedQueryStruct(ctx context.Context, log *zap.SugaredLogger, db *sqlx.DB,
 	ctx, span := otel.GetTracerProvider().Tracer("").Start(ctx, "database.query")
 	span.SetAttributes(attribute.String("query", q))
 	defer span.End()
-	rows, err := db.NamedQueryContext(ctx, query, data)
+	rows, err := sqlx.NamedQueryContext(ctx, executor(ctx, db), query, data)
 
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
 	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
 		return ErrNotFound
 	}
 
@@ -0,0 +1,166 @@
+// Package events provides an in-process bus for domain events. Events are
+// carried as JSON in an Envelope so the same value can be stored in the
+// outbox, read back after a restart and handed to typed subscribers.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Event is implemented by every domain event. The name identifies the type of
+// the event and is what subscribers are registered against.
+type Event interface {
+	EventName() string
+}
+
+// Envelope is an event in its transport form.
+type Envelope struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// Handler receives an event in its transport form.
+type Handler func(ctx context.Context, env Envelope) error
+
+type subscriber struct {
+	handler Handler
+	async   bool
+}
+
+// Bus dispatches events to the subscribers registered for their name.
+type Bus struct {
+	log  *zap.SugaredLogger
+	mu   sync.RWMutex
+	subs map[string][]subscriber
+	all  []subscriber
+	wg   sync.WaitGroup
+}
+
+// New constructs a Bus without subscribers.
+func New(log *zap.SugaredLogger) *Bus {
+	return &Bus{
+		log:  log,
+		subs: make(map[string][]subscriber),
+	}
+}
+
+// Subscribe registers fn to run synchronously for every event of type T. An
+// error from fn fails the publish so the event is delivered again later.
+func Subscribe[T Event](b *Bus, fn func(ctx context.Context, evt T) error) {
+	var evt T
+	b.add(evt.EventName(), subscriber{handler: typed(fn)})
+}
+
+// SubscribeAsync registers fn to run in its own goroutine for every event of
+// type T. Errors are logged and the event is not delivered again.
+func SubscribeAsync[T Event](b *Bus, fn func(ctx context.Context, evt T) error) {
+	var evt T
+	b.add(evt.EventName(), subscriber{handler: typed(fn), async: true})
+}
+
+// SubscribeAll registers fn to run synchronously for every event, whatever its
+// type. It is meant for subscribers that forward events without looking into
+// them.
+func (b *Bus) SubscribeAll(fn Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.all = append(b.all, subscriber{handler: fn})
+}
+
+func (b *Bus) add(name string, sub subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subs[name] = append(b.subs[name], sub)
+}
+
+// Publish hands the event to its subscribers. Synchronous subscribers run in
+// the order they were registered and the first error is returned, async
+// subscribers are started once all synchronous ones succeeded.
+func (b *Bus) Publish(ctx context.Context, env Envelope) error {
+	b.mu.RLock()
+	subs := make([]subscriber, 0, len(b.all)+len(b.subs[env.Name]))
+	subs = append(subs, b.all...)
+	subs = append(subs, b.subs[env.Name]...)
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		if sub.async {
+			continue
+		}
+		if err := sub.handler(ctx, env); err != nil {
+			return fmt.Errorf("handling event[%s] id[%s]: %w", env.Name, env.ID, err)
+		}
+	}
+
+	for _, sub := range subs {
+		if !sub.async {
+			continue
+		}
+
+		b.wg.Add(1)
+		go func(h Handler) {
+			defer b.wg.Done()
+
+			// The publishing context may end before the subscriber does.
+			if err := h(context.Background(), env); err != nil {
+				b.log.Errorw("events", "status", "async subscriber failed", "name", env.Name, "id", env.ID, "ERROR", err)
+			}
+		}(sub.handler)
+	}
+
+	return nil
+}
+
+// Wait blocks until every running async subscriber has returned or the
+// context is done.
+func (b *Bus) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// NewEnvelope converts an event into its transport form.
+func NewEnvelope(id string, evt Event, now time.Time) (Envelope, error) {
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("encoding event[%s]: %w", evt.EventName(), err)
+	}
+
+	env := Envelope{
+		ID:         id,
+		Name:       evt.EventName(),
+		Payload:    payload,
+		OccurredAt: now,
+	}
+
+	return env, nil
+}
+
+func typed[T Event](fn func(ctx context.Context, evt T) error) Handler {
+	return func(ctx context.Context, env Envelope) error {
+		var evt T
+		if err := json.Unmarshal(env.Payload, &evt); err != nil {
+			return fmt.Errorf("decoding event[%s]: %w", env.Name, err)
+		}
+		return fn(ctx, evt)
+	}
+}
@@ -0,0 +1,91 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/events"
+	"go.uber.org/zap"
+)
+
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+type orderPlaced struct {
+	OrderID string `json:"order_id"`
+}
+
+func (orderPlaced) EventName() string { return "order.placed" }
+
+func TestBus(t *testing.T) {
+	bus := events.New(zap.NewNop().Sugar())
+
+	var order []string
+	events.Subscribe(bus, func(ctx context.Context, evt orderPlaced) error {
+		order = append(order, "sync:"+evt.OrderID)
+		return nil
+	})
+
+	async := make(chan string, 1)
+	events.SubscribeAsync(bus, func(ctx context.Context, evt orderPlaced) error {
+		async <- evt.OrderID
+		return nil
+	})
+
+	env, err := events.NewEnvelope("1", orderPlaced{OrderID: "42"}, time.Now())
+	if err != nil {
+		t.Fatalf("Should be able to construct an envelope: %v", err)
+	}
+
+	t.Log("Given the need to dispatch events to subscribers.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen publishing an event with sync and async subscribers.", testID)
+		{
+			if err := bus.Publish(context.Background(), env); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to publish the event: %v", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to publish the event.", success, testID)
+
+			if len(order) != 1 || order[0] != "sync:42" {
+				t.Fatalf("\t%s\tTest %d:\tShould run the sync subscriber with the typed event: %v", failed, testID, order)
+			}
+			t.Logf("\t%s\tTest %d:\tShould run the sync subscriber with the typed event.", success, testID)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := bus.Wait(ctx); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to wait for the async subscriber: %v", failed, testID, err)
+			}
+			if got := <-async; got != "42" {
+				t.Fatalf("\t%s\tTest %d:\tShould run the async subscriber with the typed event: %s", failed, testID, got)
+			}
+			t.Logf("\t%s\tTest %d:\tShould run the async subscriber with the typed event.", success, testID)
+		}
+
+		testID = 1
+		t.Logf("\tTest %d:\tWhen a sync subscriber fails.", testID)
+		{
+			errBoom := errors.New("boom")
+			events.Subscribe(bus, func(ctx context.Context, evt orderPlaced) error {
+				return errBoom
+			})
+
+			if err := bus.Publish(context.Background(), env); !errors.Is(err, errBoom) {
+				t.Fatalf("\t%s\tTest %d:\tShould return the error of the subscriber: %v", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould return the error of the subscriber.", success, testID)
+
+			select {
+			case id := <-async:
+				t.Fatalf("\t%s\tTest %d:\tShould not start async subscribers: got %s", failed, testID, id)
+			case <-time.After(50 * time.Millisecond):
+			}
+			t.Logf("\t%s\tTest %d:\tShould not start async subscribers.", success, testID)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Package mail defines how the service sends email and provides
+// implementations for local development that never leave the machine.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends email. Production deployments provide an implementation backed
+// by a real mail service.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer writes every message to the log instead of sending it.
+type LogMailer struct {
+	log *zap.SugaredLogger
+}
+
+// NewLogMailer constructs a Mailer that logs messages.
+func NewLogMailer(log *zap.SugaredLogger) LogMailer {
+	return LogMailer{log: log}
+}
+
+// Send logs the message.
+func (m LogMailer) Send(ctx context.Context, msg Message) error {
+	m.log.Infow("mail", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// FileMailer writes every message to its own file in a folder.
+type FileMailer struct {
+	folder string
+}
+
+// NewFileMailer constructs a Mailer that writes messages to the folder,
+// creating it if needed.
+func NewFileMailer(folder string) (FileMailer, error) {
+	if err := os.MkdirAll(folder, 0700); err != nil {
+		return FileMailer{}, fmt.Errorf("creating mail folder: %w", err)
+	}
+	return FileMailer{folder: folder}, nil
+}
+
+// Send writes the message to a file named after the time and recipient.
+func (m FileMailer) Send(ctx context.Context, msg Message) error {
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.NewReplacer("/", "_", "@", "_at_").Replace(msg.To))
+
+	data := fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s\r\n", msg.To, msg.Subject, msg.Body)
+	if err := os.WriteFile(filepath.Join(m.folder, name), []byte(data), 0600); err != nil {
+		return fmt.Errorf("writing mail: %w", err)
+	}
+
+	return nil
+}
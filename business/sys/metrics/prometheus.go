@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// buckets are the upper bounds, in seconds, of the request duration histogram.
+var buckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var prom = &registry{
+	durations: make(map[requestLabels]*histogram),
+	inFlight:  make(map[routeLabels]int64),
+}
+
+type routeLabels struct {
+	route  string
+	method string
+}
+
+type requestLabels struct {
+	routeLabels
+	status int
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+type registry struct {
+	mu        sync.Mutex
+	durations map[requestLabels]*histogram
+	inFlight  map[routeLabels]int64
+}
+
+// StatsReporter is implemented by *sql.DB and *sqlx.DB.
+type StatsReporter interface {
+	Stats() sql.DBStats
+}
+
+// RequestStarted marks a request for the route template and method as in
+// flight.
+func RequestStarted(route string, method string) {
+	prom.mu.Lock()
+	defer prom.mu.Unlock()
+
+	prom.inFlight[routeLabels{route: route, method: method}]++
+}
+
+// RequestFinished removes the request from the in-flight gauge and records
+// its duration against the route template, method and response status.
+func RequestFinished(route string, method string, status int, d time.Duration) {
+	rl := routeLabels{route: route, method: method}
+	rql := requestLabels{routeLabels: rl, status: status}
+
+	prom.mu.Lock()
+	defer prom.mu.Unlock()
+
+	prom.inFlight[rl]--
+
+	h, exists := prom.durations[rql]
+	if !exists {
+		h = &histogram{counts: make([]uint64, len(buckets))}
+		prom.durations[rql] = h
+	}
+
+	secs := d.Seconds()
+	for i, le := range buckets {
+		if secs <= le {
+			h.counts[i]++
+		}
+	}
+	h.sum += secs
+	h.count++
+}
+
+// Handler returns a handler that serves every metric in the Prometheus text
+// exposition format. Pool statistics are read from db on each scrape.
+func Handler(db StatsReporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		var stats sql.DBStats
+		if db != nil {
+			stats = db.Stats()
+		}
+
+		WritePrometheus(w, stats)
+	})
+}
+
+// WritePrometheus writes every metric in the Prometheus text exposition format.
+func WritePrometheus(w io.Writer, stats sql.DBStats) {
+	writeCounter(w, "sales_requests_total", "Total number of requests handled.", m.requests.Value())
+	writeCounter(w, "sales_errors_total", "Total number of requests that returned an error.", m.errors.Value())
+	writeCounter(w, "sales_panics_total", "Total number of requests that panicked.", m.panics.Value())
+	writeGauge(w, "sales_goroutines", "Number of goroutines sampled every 100 requests.", float64(m.goroutines.Value()))
+
+	prom.mu.Lock()
+	writeInFlight(w)
+	writeDurations(w)
+	prom.mu.Unlock()
+
+	writeGauge(w, "sales_db_max_open_connections", "Maximum number of open connections to the database.", float64(stats.MaxOpenConnections))
+	writeGauge(w, "sales_db_open_connections", "Number of established connections both in use and idle.", float64(stats.OpenConnections))
+	writeGauge(w, "sales_db_in_use_connections", "Number of connections currently in use.", float64(stats.InUse))
+	writeGauge(w, "sales_db_idle_connections", "Number of idle connections.", float64(stats.Idle))
+	writeCounter(w, "sales_db_wait_count_total", "Total number of connections waited for.", stats.WaitCount)
+	writeCounter(w, "sales_db_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", stats.WaitDuration.Seconds())
+	writeCounter(w, "sales_db_max_idle_closed_total", "Total number of connections closed due to SetMaxIdleConns.", stats.MaxIdleClosed)
+	writeCounter(w, "sales_db_max_idle_time_closed_total", "Total number of connections closed due to SetConnMaxIdleTime.", stats.MaxIdleTimeClosed)
+	writeCounter(w, "sales_db_max_lifetime_closed_total", "Total number of connections closed due to SetConnMaxLifetime.", stats.MaxLifetimeClosed)
+}
+
+func writeInFlight(w io.Writer) {
+	const name = "sales_http_requests_in_flight"
+
+	keys := make([]routeLabels, 0, len(prom.inFlight))
+	for k := range prom.inFlight {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].route+keys[i].method < keys[j].route+keys[j].method
+	})
+
+	fmt.Fprintf(w, "# HELP %s Number of requests currently being served.\n", name)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s{route=%s,method=%s} %d\n", name, quote(k.route), quote(k.method), prom.inFlight[k])
+	}
+}
+
+func writeDurations(w io.Writer) {
+	const name = "sales_http_request_duration_seconds"
+
+	keys := make([]requestLabels, 0, len(prom.durations))
+	for k := range prom.durations {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].status < keys[j].status
+	})
+
+	fmt.Fprintf(w, "# HELP %s Duration of requests by route template, method and status.\n", name)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+	for _, k := range keys {
+		h := prom.durations[k]
+		labels := fmt.Sprintf("route=%s,method=%s,status=%s", quote(k.route), quote(k.method), quote(strconv.Itoa(k.status)))
+
+		for i, le := range buckets {
+			fmt.Fprintf(w, "%s_bucket{%s,le=%s} %d\n", name, labels, quote(formatFloat(le)), h.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+	}
+}
+
+func writeCounter[T int64 | float64](w io.Writer, name string, help string, value T) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s counter\n", name)
+	fmt.Fprintf(w, "%s %s\n", name, formatFloat(float64(value)))
+}
+
+func writeGauge(w io.Writer, name string, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+	fmt.Fprintf(w, "%s %s\n", name, formatFloat(value))
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// quote escapes a label value as required by the exposition format.
+func quote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,54 @@
+package metrics_test
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/metrics"
+)
+
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	metrics.RequestStarted("/v1/users/:id", http.MethodGet)
+	metrics.RequestStarted("/v1/users/:id", http.MethodGet)
+	metrics.RequestFinished("/v1/users/:id", http.MethodGet, http.StatusOK, 30*time.Millisecond)
+
+	var buf bytes.Buffer
+	metrics.WritePrometheus(&buf, sql.DBStats{OpenConnections: 3, InUse: 1, Idle: 2})
+	out := buf.String()
+
+	t.Log("Given the need to expose metrics in the Prometheus text format.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen a request has been recorded.", testID)
+		{
+			want := []string{
+				"# TYPE sales_http_request_duration_seconds histogram",
+				`sales_http_request_duration_seconds_bucket{route="/v1/users/:id",method="GET",status="200",le="0.025"} 0`,
+				`sales_http_request_duration_seconds_bucket{route="/v1/users/:id",method="GET",status="200",le="0.05"} 1`,
+				`sales_http_request_duration_seconds_bucket{route="/v1/users/:id",method="GET",status="200",le="+Inf"} 1`,
+				`sales_http_request_duration_seconds_count{route="/v1/users/:id",method="GET",status="200"} 1`,
+				`sales_http_requests_in_flight{route="/v1/users/:id",method="GET"} 1`,
+				"sales_db_open_connections 3",
+				"sales_db_in_use_connections 1",
+				"# TYPE sales_panics_total counter",
+			}
+
+			for _, line := range want {
+				if !strings.Contains(out, line+"\n") {
+					t.Log(out)
+					t.Fatalf("\t%s\tTest %d:\tShould contain %q.", failed, testID, line)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould contain the histogram, gauges and counters.", success, testID)
+		}
+	}
+}
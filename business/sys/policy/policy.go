@@ -0,0 +1,252 @@
+// Package policy declares who may perform each action of the service. Most
+// actions need a permission the roles of the caller are granted, as resolved
+// into auth.Claims.Granted when the request is authenticated. The rules are
+// evaluated by the route middleware, with what the request tells about the
+// resource, and again by the stores once the resource is loaded.
+package policy
+
+import (
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+)
+
+// Action names something a caller can do to a resource.
+type Action string
+
+// Set of actions the service authorizes.
+const (
+	UserCreate          Action = "user:create"
+	UserQuery           Action = "user:query"
+	UserRead            Action = "user:read"
+	UserUpdate          Action = "user:update"
+	UserAssignRoles     Action = "user:assign_roles"
+	UserGrantSuperAdmin Action = "user:grant_super_admin"
+	UserSetPassword     Action = "user:set_password"
+	UserChangePassword  Action = "user:change_password"
+	UserDelete          Action = "user:delete"
+	UserQueryDeleted    Action = "user:query_deleted"
+	UserRestore         Action = "user:restore"
+	UserPurge           Action = "user:purge"
+	TokenRevoke         Action = "token:revoke"
+	APIKeyCreate        Action = "apikey:create"
+	APIKeyQuery         Action = "apikey:query"
+	APIKeyRevoke        Action = "apikey:revoke"
+	ClientCreate        Action = "client:create"
+	ClientQuery         Action = "client:query"
+	ClientDelete        Action = "client:delete"
+	ProductCreate       Action = "product:create"
+	ProductQuery        Action = "product:query"
+	ProductRead         Action = "product:read"
+	ProductUpdate       Action = "product:update"
+	ProductDelete       Action = "product:delete"
+	SaleCreate          Action = "sale:create"
+	SaleQuery           Action = "sale:query"
+	SaleRead            Action = "sale:read"
+	SaleQueryByUser     Action = "sale:query_by_user"
+	AuditRead           Action = "audit:read"
+	RoleWrite           Action = "role:write"
+	WebhookRead         Action = "webhook:read"
+	WebhookWrite        Action = "webhook:write"
+	TenantRead          Action = "tenant:read"
+	TenantWrite         Action = "tenant:write"
+	TestAuth            Action = "test:auth"
+)
+
+// Resource holds the attributes of the resource the rules look at. Fields a
+// rule does not need can be left empty.
+type Resource struct {
+	OwnerID  string
+	TenantID string
+}
+
+// Rule decides whether the caller described by the claims may act on the
+// resource.
+type Rule func(claims auth.Claims, res Resource) bool
+
+// Authenticated allows every caller with valid claims.
+func Authenticated() Rule {
+	return func(claims auth.Claims, res Resource) bool {
+		return claims.Subject != ""
+	}
+}
+
+// HasRole allows callers with any of the roles.
+func HasRole(roles ...auth.Role) Rule {
+	return func(claims auth.Claims, res Resource) bool {
+		return claims.Authorized(roles...)
+	}
+}
+
+// HasPermission allows callers whose roles grant the permission.
+func HasPermission(perm auth.Permission) Rule {
+	return func(claims auth.Claims, res Resource) bool {
+		return claims.Permitted(perm)
+	}
+}
+
+// IsOwner allows the user that owns the resource.
+func IsOwner() Rule {
+	return func(claims auth.Claims, res Resource) bool {
+		return claims.Subject != "" && claims.Subject == res.OwnerID
+	}
+}
+
+// SameTenant allows callers that belong to the tenant of the resource.
+func SameTenant() Rule {
+	return func(claims auth.Claims, res Resource) bool {
+		return claims.TenantID == res.TenantID
+	}
+}
+
+// AnyOf allows the caller when at least one of the rules does.
+func AnyOf(rules ...Rule) Rule {
+	return func(claims auth.Claims, res Resource) bool {
+		for _, rule := range rules {
+			if rule(claims, res) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// AllOf allows the caller when every one of the rules does.
+func AllOf(rules ...Rule) Rule {
+	return func(claims auth.Claims, res Resource) bool {
+		for _, rule := range rules {
+			if !rule(claims, res) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Commonly used combinations. Every rule that looks at a resource first
+// requires the caller to be in the tenant of the resource. Super admins
+// manage what is shared by all tenants and are not bound to one.
+var (
+	superAdmin = HasRole(auth.RoleSuperAdmin)
+	owner      = AllOf(SameTenant(), IsOwner())
+	member     = AllOf(SameTenant(), Authenticated())
+)
+
+// permitted allows callers whose roles grant the permission.
+func permitted(perm auth.Permission) Rule {
+	return AllOf(SameTenant(), HasPermission(perm))
+}
+
+// permittedOrOwner allows callers whose roles grant the permission, and the
+// user owning the resource without it.
+func permittedOrOwner(perm auth.Permission) Rule {
+	return AllOf(SameTenant(), AnyOf(HasPermission(perm), IsOwner()))
+}
+
+// permittedAsOwner allows callers whose roles grant the permission on what
+// they own, and admins on what anyone owns.
+func permittedAsOwner(perm auth.Permission) Rule {
+	return AllOf(SameTenant(), HasPermission(perm), AnyOf(HasRole(auth.RoleAdmin), IsOwner()))
+}
+
+var rules = map[Action]Rule{
+	UserCreate:          permitted(auth.PermUsersWrite),
+	UserQuery:           member,
+	UserRead:            permittedOrOwner(auth.PermUsersRead),
+	UserUpdate:          permittedOrOwner(auth.PermUsersWrite),
+	UserAssignRoles:     permitted(auth.PermUsersWrite),
+	UserGrantSuperAdmin: superAdmin,
+	UserSetPassword:     permitted(auth.PermUsersWrite),
+	UserChangePassword:  owner,
+	UserDelete:          permitted(auth.PermUsersWrite),
+	UserQueryDeleted:    permitted(auth.PermUsersRead),
+	UserRestore:         permitted(auth.PermUsersWrite),
+	UserPurge:           permitted(auth.PermUsersWrite),
+	TokenRevoke:         permittedOrOwner(auth.PermUsersWrite),
+	APIKeyCreate:        member,
+	APIKeyQuery:         permittedOrOwner(auth.PermUsersRead),
+	APIKeyRevoke:        permittedOrOwner(auth.PermUsersWrite),
+	ClientCreate:        member,
+	ClientQuery:         permittedOrOwner(auth.PermUsersRead),
+	ClientDelete:        permittedOrOwner(auth.PermUsersWrite),
+	ProductCreate:       permitted(auth.PermProductsWrite),
+	ProductQuery:        permitted(auth.PermProductsRead),
+	ProductRead:         permitted(auth.PermProductsRead),
+	ProductUpdate:       permittedAsOwner(auth.PermProductsWrite),
+	ProductDelete:       permittedAsOwner(auth.PermProductsWrite),
+	SaleCreate:          permitted(auth.PermSalesWrite),
+	SaleQuery:           AllOf(permitted(auth.PermSalesRead), HasRole(auth.RoleAdmin)),
+	SaleRead:            permittedAsOwner(auth.PermSalesRead),
+	SaleQueryByUser:     permittedAsOwner(auth.PermSalesRead),
+	AuditRead:           permitted(auth.PermAuditRead),
+	RoleWrite:           superAdmin,
+	WebhookRead:         superAdmin,
+	WebhookWrite:        superAdmin,
+	TenantRead:          superAdmin,
+	TenantWrite:         superAdmin,
+	TestAuth:            AllOf(SameTenant(), HasRole(auth.RoleAdmin)),
+}
+
+// scopes maps each action to the permission claims that list permissions, as
+// those of a scoped API key do, must list to perform it. Actions missing here
+// are denied to such claims.
+var scopes = map[Action]auth.Permission{
+	UserCreate:          auth.PermUsersWrite,
+	UserQuery:           auth.PermUsersRead,
+	UserRead:            auth.PermUsersRead,
+	UserUpdate:          auth.PermUsersWrite,
+	UserAssignRoles:     auth.PermUsersWrite,
+	UserGrantSuperAdmin: auth.PermUsersWrite,
+	UserSetPassword:     auth.PermUsersWrite,
+	UserChangePassword:  auth.PermUsersWrite,
+	UserDelete:          auth.PermUsersWrite,
+	UserQueryDeleted:    auth.PermUsersRead,
+	UserRestore:         auth.PermUsersWrite,
+	UserPurge:           auth.PermUsersWrite,
+	TokenRevoke:         auth.PermUsersWrite,
+	APIKeyCreate:        auth.PermUsersWrite,
+	APIKeyQuery:         auth.PermUsersRead,
+	APIKeyRevoke:        auth.PermUsersWrite,
+	ClientCreate:        auth.PermUsersWrite,
+	ClientQuery:         auth.PermUsersRead,
+	ClientDelete:        auth.PermUsersWrite,
+	ProductCreate:       auth.PermProductsWrite,
+	ProductQuery:        auth.PermProductsRead,
+	ProductRead:         auth.PermProductsRead,
+	ProductUpdate:       auth.PermProductsWrite,
+	ProductDelete:       auth.PermProductsWrite,
+	SaleCreate:          auth.PermSalesWrite,
+	SaleQuery:           auth.PermSalesRead,
+	SaleRead:            auth.PermSalesRead,
+	SaleQueryByUser:     auth.PermSalesRead,
+	AuditRead:           auth.PermAuditRead,
+	RoleWrite:           auth.PermRolesWrite,
+	WebhookRead:         auth.PermWebhooksRead,
+	WebhookWrite:        auth.PermWebhooksWrite,
+}
+
+// Allowed reports whether the caller described by the claims may perform the
+// action on the resource. Actions without a rule are denied, and so are
+// actions outside the permissions the claims list.
+func Allowed(claims auth.Claims, action Action, res Resource) bool {
+	rule, exists := rules[action]
+	if !exists {
+		return false
+	}
+	if len(claims.Permissions) > 0 && !inScope(claims, action) {
+		return false
+	}
+	return rule(claims, res)
+}
+
+// inScope reports whether the permissions the claims list cover the action.
+func inScope(claims auth.Claims, action Action) bool {
+	perm, exists := scopes[action]
+	if !exists {
+		return false
+	}
+	for _, has := range claims.Permissions {
+		if has == perm {
+			return true
+		}
+	}
+	return false
+}
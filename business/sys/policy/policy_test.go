@@ -0,0 +1,236 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/policy"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+const (
+	superID = "9a6f1e2d-3c4b-4a59-8e7f-6d5c4b3a2910"
+	adminID = "5cf37266-3473-4006-984f-9325122678b7"
+	ownerID = "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+	otherID = "b2e48272-2222-4106-888f-9325122678b2"
+)
+
+// grants mirrors the permissions the schema grants the built-in roles.
+var grants = map[auth.Role][]auth.Permission{
+	auth.RoleSuperAdmin: {
+		auth.PermProductsRead, auth.PermProductsWrite,
+		auth.PermSalesRead, auth.PermSalesWrite,
+		auth.PermRolesWrite,
+	},
+	auth.RoleAdmin: {
+		auth.PermUsersRead, auth.PermUsersWrite,
+		auth.PermProductsRead, auth.PermProductsWrite,
+		auth.PermSalesRead, auth.PermSalesWrite,
+		auth.PermRolesRead,
+		auth.PermAuditRead,
+		auth.PermWebhooksRead, auth.PermWebhooksWrite,
+	},
+	auth.RoleUser: {
+		auth.PermProductsRead, auth.PermProductsWrite,
+		auth.PermSalesRead, auth.PermSalesWrite,
+	},
+}
+
+func newClaims(subject string, roles ...auth.Role) auth.Claims {
+	var granted []auth.Permission
+	for _, role := range roles {
+		granted = append(granted, grants[role]...)
+	}
+
+	return auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: subject},
+		Roles:            roles,
+		Granted:          granted,
+	}
+}
+
+// TestAccessMatrix documents, per route, which callers the action behind the
+// route allows: a super admin, an admin, the user owning the resource and any
+// other user.
+func TestAccessMatrix(t *testing.T) {
+	super := newClaims(superID, auth.RoleSuperAdmin)
+	admin := newClaims(adminID, auth.RoleAdmin)
+	owner := newClaims(ownerID, auth.RoleUser)
+	other := newClaims(otherID, auth.RoleUser)
+	res := policy.Resource{OwnerID: ownerID}
+
+	matrix := []struct {
+		route  string
+		action policy.Action
+		super  bool
+		admin  bool
+		owner  bool
+		other  bool
+	}{
+		{"GET /v1/testauth", policy.TestAuth, false, true, false, false},
+		{"GET /v1/users", policy.UserQuery, true, true, true, true},
+		{"GET /v1/users/:id", policy.UserRead, false, true, true, false},
+		{"GET /v1/users/me", policy.UserRead, false, true, true, false},
+		{"POST /v1/users", policy.UserCreate, false, true, false, false},
+		{"PUT /v1/users/:id", policy.UserUpdate, false, true, true, false},
+		{"PUT /v1/users/:id roles", policy.UserAssignRoles, false, true, false, false},
+		{"PUT /v1/users/:id SUPER_ADMIN role", policy.UserGrantSuperAdmin, true, false, false, false},
+		{"PUT /v1/users/:id password", policy.UserSetPassword, false, true, false, false},
+		{"PUT /v1/users/me", policy.UserUpdate, false, true, true, false},
+		{"PUT /v1/users/me/password", policy.UserChangePassword, false, false, true, false},
+		{"DELETE /v1/users/:id", policy.UserDelete, false, true, false, false},
+		{"GET /v1/users/deleted", policy.UserQueryDeleted, false, true, false, false},
+		{"POST /v1/users/:id/restore", policy.UserRestore, false, true, false, false},
+		{"POST /v1/users/purge", policy.UserPurge, false, true, false, false},
+		{"POST /v1/users/token/revoke", policy.TokenRevoke, false, true, true, false},
+		{"POST /v1/users/me/apikeys", policy.APIKeyCreate, true, true, true, true},
+		{"GET /v1/users/:id/apikeys", policy.APIKeyQuery, false, true, true, false},
+		{"DELETE /v1/users/:id/apikeys/:key_id", policy.APIKeyRevoke, false, true, true, false},
+		{"POST /oauth/clients", policy.ClientCreate, true, true, true, true},
+		{"GET /oauth/clients", policy.ClientQuery, false, true, true, false},
+		{"DELETE /oauth/clients/:client_id", policy.ClientDelete, false, true, true, false},
+		{"GET /v1/products/:page/:rows", policy.ProductQuery, true, true, true, true},
+		{"GET /v1/products/:id", policy.ProductRead, true, true, true, true},
+		{"POST /v1/products", policy.ProductCreate, true, true, true, true},
+		{"PUT /v1/products/:id", policy.ProductUpdate, false, true, true, false},
+		{"DELETE /v1/products/:id", policy.ProductDelete, false, true, true, false},
+		{"GET /v1/sales/:page/:rows", policy.SaleQuery, false, true, false, false},
+		{"GET /v1/sales/:id", policy.SaleRead, false, true, true, false},
+		{"GET /v1/users/:id/sales", policy.SaleQueryByUser, false, true, true, false},
+		{"POST /v1/sales", policy.SaleCreate, true, true, true, true},
+		{"GET /v1/audit", policy.AuditRead, false, true, false, false},
+		{"POST /v1/roles", policy.RoleWrite, true, false, false, false},
+		{"PUT /v1/roles/:name", policy.RoleWrite, true, false, false, false},
+		{"DELETE /v1/roles/:name", policy.RoleWrite, true, false, false, false},
+		{"GET /v1/webhooks", policy.WebhookRead, true, false, false, false},
+		{"GET /v1/webhooks/:id", policy.WebhookRead, true, false, false, false},
+		{"GET /v1/webhooks/:id/deliveries", policy.WebhookRead, true, false, false, false},
+		{"POST /v1/webhooks", policy.WebhookWrite, true, false, false, false},
+		{"PUT /v1/webhooks/:id", policy.WebhookWrite, true, false, false, false},
+		{"DELETE /v1/webhooks/:id", policy.WebhookWrite, true, false, false, false},
+		{"POST /v1/webhooks/:id/deliveries/:delivery_id/redeliver", policy.WebhookWrite, true, false, false, false},
+		{"GET /v1/tenants", policy.TenantRead, true, false, false, false},
+		{"GET /v1/tenants/:id", policy.TenantRead, true, false, false, false},
+		{"POST /v1/tenants", policy.TenantWrite, true, false, false, false},
+		{"PUT /v1/tenants/:id", policy.TenantWrite, true, false, false, false},
+		{"DELETE /v1/tenants/:id", policy.TenantWrite, true, false, false, false},
+	}
+
+	t.Log("Given the need to authorize every route with a single policy.")
+	{
+		for testID, row := range matrix {
+			t.Logf("\tTest %d:\tWhen calling %s.", testID, row.route)
+			{
+				callers := []struct {
+					name   string
+					claims auth.Claims
+					exp    bool
+				}{
+					{"a super admin", super, row.super},
+					{"an admin", admin, row.admin},
+					{"the owner", owner, row.owner},
+					{"another user", other, row.other},
+				}
+				for _, c := range callers {
+					if got := policy.Allowed(c.claims, row.action, res); got != c.exp {
+						t.Fatalf("\t%s\tTest %d:\tShould allow %s %v: got %v.", failed, testID, c.name, c.exp, got)
+					}
+					t.Logf("\t%s\tTest %d:\tShould allow %s %v.", success, testID, c.name, c.exp)
+				}
+			}
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	admin := newClaims(adminID, auth.RoleAdmin)
+
+	t.Log("Given the need to evaluate rules against the caller and the resource.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen the caller is in another tenant.", testID)
+		{
+			res := policy.Resource{OwnerID: adminID, TenantID: "acme"}
+			if policy.Allowed(admin, policy.UserRead, res) {
+				t.Fatalf("\t%s\tTest %d:\tShould deny an admin of another tenant.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould deny an admin of another tenant.", success, testID)
+
+			admin.TenantID = "acme"
+			if !policy.Allowed(admin, policy.UserRead, res) {
+				t.Fatalf("\t%s\tTest %d:\tShould allow an admin of the same tenant.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould allow an admin of the same tenant.", success, testID)
+		}
+
+		testID = 1
+		t.Logf("\tTest %d:\tWhen checking an action without a rule.", testID)
+		{
+			if policy.Allowed(admin, policy.Action("unknown:action"), policy.Resource{}) {
+				t.Fatalf("\t%s\tTest %d:\tShould deny the action.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould deny the action.", success, testID)
+		}
+
+		testID = 2
+		t.Logf("\tTest %d:\tWhen the caller is anonymous.", testID)
+		{
+			if policy.Allowed(auth.Claims{}, policy.ProductQuery, policy.Resource{}) {
+				t.Fatalf("\t%s\tTest %d:\tShould deny the caller.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould deny the caller.", success, testID)
+
+			if policy.Allowed(auth.Claims{}, policy.ProductUpdate, policy.Resource{}) {
+				t.Fatalf("\t%s\tTest %d:\tShould not treat an empty subject as the owner.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not treat an empty subject as the owner.", success, testID)
+		}
+
+		testID = 3
+		t.Logf("\tTest %d:\tWhen the caller holds a custom role.", testID)
+		{
+			auditor := newClaims(otherID, auth.MustParseRole("AUDITOR"))
+			auditor.Granted = []auth.Permission{auth.PermUsersRead, auth.PermAuditRead}
+
+			if !policy.Allowed(auditor, policy.UserRead, policy.Resource{OwnerID: ownerID}) {
+				t.Fatalf("\t%s\tTest %d:\tShould allow what the role grants.", failed, testID)
+			}
+			if !policy.Allowed(auditor, policy.AuditRead, policy.Resource{}) {
+				t.Fatalf("\t%s\tTest %d:\tShould allow what the role grants.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould allow what the role grants.", success, testID)
+
+			if policy.Allowed(auditor, policy.UserDelete, policy.Resource{OwnerID: ownerID}) {
+				t.Fatalf("\t%s\tTest %d:\tShould deny what the role does not grant.", failed, testID)
+			}
+			if policy.Allowed(auditor, policy.ProductQuery, policy.Resource{}) {
+				t.Fatalf("\t%s\tTest %d:\tShould deny what the role does not grant.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould deny what the role does not grant.", success, testID)
+		}
+
+		testID = 4
+		t.Logf("\tTest %d:\tWhen the caller uses an API key scoped to permissions.", testID)
+		{
+			key := newClaims(adminID, auth.RoleAdmin)
+			key.Permissions = []auth.Permission{auth.PermProductsRead}
+
+			if !policy.Allowed(key, policy.ProductQuery, policy.Resource{}) {
+				t.Fatalf("\t%s\tTest %d:\tShould allow what the key is scoped to.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould allow what the key is scoped to.", success, testID)
+
+			for _, action := range []policy.Action{policy.UserCreate, policy.UserDelete, policy.UserPurge, policy.ProductUpdate, policy.TestAuth} {
+				if policy.Allowed(key, action, policy.Resource{OwnerID: adminID}) {
+					t.Fatalf("\t%s\tTest %d:\tShould deny %s outside the scope of the key.", failed, testID, action)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould deny actions outside the scope of the key.", success, testID)
+		}
+	}
+}
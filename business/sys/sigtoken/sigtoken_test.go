@@ -0,0 +1,67 @@
+package sigtoken_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/sigtoken"
+)
+
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestSigner(t *testing.T) {
+	s, err := sigtoken.New([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("Should be able to construct a signer: %v", err)
+	}
+
+	now := time.Now()
+	const subject = "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+
+	token, err := s.Sign("verify", subject, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Should be able to sign a token: %v", err)
+	}
+
+	other, err := sigtoken.New([]byte("fedcba9876543210fedcba9876543210"))
+	if err != nil {
+		t.Fatalf("Should be able to construct a signer: %v", err)
+	}
+
+	tt := []struct {
+		name    string
+		signer  *sigtoken.Signer
+		purpose string
+		token   string
+		now     time.Time
+		err     error
+	}{
+		{name: "valid", signer: s, purpose: "verify", token: token, now: now},
+		{name: "expired", signer: s, purpose: "verify", token: token, now: now.Add(time.Hour), err: sigtoken.ErrExpired},
+		{name: "wrong-purpose", signer: s, purpose: "reset", token: token, now: now, err: sigtoken.ErrInvalid},
+		{name: "wrong-key", signer: other, purpose: "verify", token: token, now: now, err: sigtoken.ErrInvalid},
+		{name: "tampered", signer: s, purpose: "verify", token: "x" + token, now: now, err: sigtoken.ErrInvalid},
+		{name: "malformed", signer: s, purpose: "verify", token: "garbage", now: now, err: sigtoken.ErrInvalid},
+	}
+
+	t.Log("Given the need to verify signed tokens.")
+	{
+		for testID, tst := range tt {
+			t.Logf("\tTest %d:\tWhen checking a %s token.", testID, tst.name)
+			{
+				got, err := tst.signer.Verify(tst.purpose, tst.token, tst.now)
+				if !errors.Is(err, tst.err) {
+					t.Fatalf("\t%s\tTest %d:\tShould get error %v, got %v.", failed, testID, tst.err, err)
+				}
+				if tst.err == nil && got != subject {
+					t.Fatalf("\t%s\tTest %d:\tShould get back the subject, got %q.", failed, testID, got)
+				}
+				t.Logf("\t%s\tTest %d:\tShould get the expected result.", success, testID)
+			}
+		}
+	}
+}
@@ -8,10 +8,18 @@ import (
 	"strings"
 
 	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/policy"
 	"github.com/Avyukth/service3-clone/business/sys/validate"
 	"github.com/Avyukth/service3-clone/foundation/web"
 )
 
+// Authenticate sets the claims of the caller from a bearer token or an API
+// key. API keys are accepted in the X-API-Key header or in the authorization
+// header with the ApiKey scheme:
+//
+//	Authorization: Bearer <token>
+//	Authorization: ApiKey <key>
+//	X-API-Key: <key>
 func Authenticate(a *auth.Auth) web.Middleware {
 	m := func(handler web.Handler) web.Handler {
 
@@ -20,15 +28,46 @@ func Authenticate(a *auth.Auth) web.Middleware {
 
 			parts := strings.Split(authString, " ")
 
-			if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-				err := errors.New("expected authorization header to be Bearer token format: bearer <token>")
+			if key := r.Header.Get("x-api-key"); key != "" {
+				parts = []string{"apikey", key}
+			}
+
+			var claims auth.Claims
+			switch {
+			case len(parts) == 2 && strings.ToLower(parts[0]) == "bearer":
+				var err error
+				claims, err = a.ValidateToken(parts[1])
+				if err != nil {
+					return validate.NewRequestError(err, http.StatusUnauthorized)
+				}
+
+				revoked, err := a.IsRevoked(ctx, claims.ID)
+				if err != nil {
+					return fmt.Errorf("checking token revocation: %w", err)
+				}
+				if revoked {
+					return validate.NewRequestError(errors.New("token has been revoked"), http.StatusUnauthorized)
+				}
+
+			case len(parts) == 2 && strings.ToLower(parts[0]) == "apikey":
+				var err error
+				claims, err = a.ValidateAPIKey(ctx, parts[1])
+				if err != nil {
+					if errors.Is(err, auth.ErrInvalidAPIKey) {
+						return validate.NewRequestError(err, http.StatusUnauthorized)
+					}
+					return fmt.Errorf("checking api key: %w", err)
+				}
+
+			default:
+				err := errors.New("expected authorization header to be Bearer token format: bearer <token>, or an api key")
 
 				return validate.NewRequestError(err, http.StatusUnauthorized)
 			}
 
-			claims, err := a.ValidateToken(parts[1])
+			claims, err := a.Grant(ctx, claims)
 			if err != nil {
-				return validate.NewRequestError(err, http.StatusUnauthorized)
+				return fmt.Errorf("resolving permissions: %w", err)
 			}
 
 			ctx = auth.SetClaims(ctx, claims)
@@ -41,6 +80,7 @@ func Authenticate(a *auth.Auth) web.Middleware {
 	return m
 }
 
+// Authorize allows the request when the caller holds any of the roles.
 func Authorize(roles ...auth.Role) web.Middleware {
 	m := func(handler web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
@@ -51,7 +91,6 @@ func Authorize(roles ...auth.Role) web.Middleware {
 			}
 
 			if !claims.Authorized(roles...) {
-
 				return validate.NewRequestError(fmt.Errorf("you are not authorized for that action, claims[%v] roles[%v]", claims.Roles, roles), http.StatusForbidden)
 			}
 
@@ -62,3 +101,71 @@ func Authorize(roles ...auth.Role) web.Middleware {
 	}
 	return m
 }
+
+// AuthorizeAction allows the request when the policy of the action allows the
+// caller. Only what the request tells about the resource is known here, the
+// stores check the action again once the resource is loaded.
+func AuthorizeAction(action policy.Action) web.Middleware {
+	return authorize(action, "")
+}
+
+// AuthorizeOwner is AuthorizeAction for routes where the named path parameter
+// holds the id of the user that owns the resource.
+func AuthorizeOwner(action policy.Action, param string) web.Middleware {
+	return authorize(action, param)
+}
+
+func authorize(action policy.Action, param string) web.Middleware {
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+			claims, err := auth.GetClaims(ctx)
+			if err != nil {
+				return validate.NewRequestError(fmt.Errorf("you are not authorized for that action, no claims"), http.StatusForbidden)
+			}
+
+			res := policy.Resource{
+				TenantID: claims.TenantID,
+			}
+			if param != "" {
+				res.OwnerID = web.Param(r, param)
+			}
+
+			if !policy.Allowed(claims, action, res) {
+				return validate.NewRequestError(fmt.Errorf("you are not authorized for that action, claims[%v] action[%s]", claims.Roles, action), http.StatusForbidden)
+			}
+
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+	return m
+}
+
+// RequirePermission allows the request when the roles of the caller together
+// grant every one of the permissions. It must run after Authenticate.
+func RequirePermission(a *auth.Auth, perms ...auth.Permission) web.Middleware {
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+			claims, err := auth.GetClaims(ctx)
+			if err != nil {
+				return validate.NewRequestError(fmt.Errorf("you are not authorized for that action, no claims"), http.StatusForbidden)
+			}
+
+			ok, err := a.HasPermissions(ctx, claims, perms...)
+			if err != nil {
+				return fmt.Errorf("checking permissions: %w", err)
+			}
+			if !ok {
+				return validate.NewRequestError(fmt.Errorf("you are not authorized for that action, claims[%v] permissions[%v]", claims.Roles, perms), http.StatusForbidden)
+			}
+
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+	return m
+}
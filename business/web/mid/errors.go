@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/Avyukth/service3-clone/business/sys/metrics"
 	"github.com/Avyukth/service3-clone/business/sys/validate"
 	"github.com/Avyukth/service3-clone/foundation/web"
 	"go.uber.org/zap"
@@ -21,6 +22,8 @@ func Errors(log *zap.SugaredLogger) web.Middleware {
 
 			if err := handler(ctx, w, r); err != nil {
 				log.Errorw("ERROR", "traceid", v.TraceID, "ERROR", err)
+				metrics.AddErrors(ctx)
+
 				var er validate.ErrorResponse
 				var status int
 				switch act := validate.Cause(err).(type) {
@@ -3,26 +3,36 @@ package mid
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/Avyukth/service3-clone/business/sys/metrics"
 	"github.com/Avyukth/service3-clone/foundation/web"
 )
 
+// Metrics updates program counters and records the duration of every request
+// by route template, method and status. It must run outside of the Errors
+// middleware so the status of a failed request is known once the handler
+// returns.
 func Metrics() web.Middleware {
 	m := func(handler web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			v, err := web.GetValues(ctx)
+			if err != nil {
+				return web.NewShutdownError("web value missing from context")
+			}
+
 			ctx = metrics.Set(ctx)
 
-			err := handler(ctx, w, r)
+			metrics.RequestStarted(v.Route, r.Method)
+
+			err = handler(ctx, w, r)
+
+			metrics.RequestFinished(v.Route, r.Method, v.StatusCode, time.Since(v.Now))
 
 			metrics.AddRequests(ctx)
 
 			metrics.AddGoroutines(ctx)
 
-			if err != nil {
-				metrics.AddErrors(ctx)
-			}
-
 			return err
 		}
 		return h
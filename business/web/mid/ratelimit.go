@@ -0,0 +1,68 @@
+package mid
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Avyukth/service3-clone/business/sys/auth"
+	"github.com/Avyukth/service3-clone/business/sys/validate"
+	"github.com/Avyukth/service3-clone/foundation/ratelimit"
+	"github.com/Avyukth/service3-clone/foundation/web"
+)
+
+// KeyFunc selects the bucket a request is counted against.
+type KeyFunc func(ctx context.Context, r *http.Request) string
+
+// KeyByIP counts requests per client IP address.
+func KeyByIP(ctx context.Context, r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// KeyBySubject counts requests per authenticated user. It must run after
+// Authenticate and falls back to the client IP when there are no claims.
+func KeyBySubject(ctx context.Context, r *http.Request) string {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil || claims.Subject == "" {
+		return "ip:" + KeyByIP(ctx, r)
+	}
+	return "sub:" + claims.Subject
+}
+
+// KeyByRoute counts every request to the route against a single bucket.
+func KeyByRoute(ctx context.Context, r *http.Request) string {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return r.URL.Path
+	}
+	return v.Route
+}
+
+// RateLimit allows limit requests per period for each key returned by keyFn.
+// Every registration gets its own set of buckets. Requests over the limit are
+// rejected with a 429 and a Retry-After header.
+func RateLimit(keyFn KeyFunc, limit int, period time.Duration) web.Middleware {
+	l := ratelimit.New(limit, period)
+
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			ok, wait := l.Allow(keyFn(ctx, r), time.Now())
+			if !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				return validate.NewRequestError(errors.New("too many requests"), http.StatusTooManyRequests)
+			}
+
+			return handler(ctx, w, r)
+		}
+		return h
+	}
+	return m
+}
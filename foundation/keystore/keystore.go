@@ -1,37 +1,100 @@
 package keystore
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"path"
 	"strings"
 	"sync"
-
-	"github.com/golang-jwt/jwt/v5"
+	"time"
 )
 
+// KeyStore holds private keys in memory. Supported key types are RSA, ECDSA
+// and Ed25519, all of which implement crypto.Signer.
 type KeyStore struct {
-	mu    sync.RWMutex
-	store map[string]*rsa.PrivateKey
+	mu      sync.RWMutex
+	store   map[string]crypto.Signer
+	retired map[string]retiredKey
+}
+
+// retiredKey is a key that has been removed from the store. It can no longer
+// sign but its public key is kept until the grace period ends so tokens that
+// were signed with it still validate.
+type retiredKey struct {
+	key   crypto.Signer
+	until time.Time
 }
 
 func New() *KeyStore {
 	return &KeyStore{
-		store: make(map[string]*rsa.PrivateKey),
+		store:   make(map[string]crypto.Signer),
+		retired: make(map[string]retiredKey),
 	}
 }
 
-func NewMap(store map[string]*rsa.PrivateKey) *KeyStore {
+func NewMap(store map[string]crypto.Signer) *KeyStore {
 	return &KeyStore{
-		store: store,
+		store:   store,
+		retired: make(map[string]retiredKey),
 	}
 }
 
 func NewFs(fsys fs.FS) (*KeyStore, error) {
+	keys, err := readFs(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMap(keys), nil
+}
+
+// Reload reads the key folder again. New and changed keys are picked up
+// immediately. Keys whose file has gone are retired: they stop signing right
+// away but their public keys stay available for the grace period. The keys are
+// left unchanged when a key can not be parsed or the folder no longer holds
+// the key of the active kid, so signing keeps working.
+func (ks *KeyStore) Reload(fsys fs.FS, activeKID string, grace time.Duration) error {
+	keys, err := readFs(fsys)
+	if err != nil {
+		return err
+	}
+	if _, exists := keys[activeKID]; !exists {
+		return fmt.Errorf("active key %s not found in folder", activeKID)
+	}
+
+	now := time.Now()
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
 
-	ks := New()
+	for kid := range ks.store {
+		if _, exists := keys[kid]; !exists {
+			ks.retire(kid, now, grace)
+		}
+	}
+
+	for kid, rk := range ks.retired {
+		if _, exists := keys[kid]; exists || !now.Before(rk.until) {
+			delete(ks.retired, kid)
+		}
+	}
+
+	ks.store = keys
+
+	return nil
+}
+
+func readFs(fsys fs.FS) (map[string]crypto.Signer, error) {
+
+	keys := make(map[string]crypto.Signer)
 	fn := func(fileName string, dirEntry fs.DirEntry, err error) error {
 		if err != nil {
 			return fmt.Errorf("walk directory failure: %w", err)
@@ -54,12 +117,12 @@ func NewFs(fsys fs.FS) (*KeyStore, error) {
 			return fmt.Errorf("failed to auth private key: %w", err)
 		}
 
-		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+		privateKey, err := ParsePrivateKey(privatePEM)
 		if err != nil {
-			return fmt.Errorf("failed to parse auth private key: %w", err)
+			return fmt.Errorf("failed to parse auth private key %s: %w", fileName, err)
 		}
 
-		ks.store[strings.TrimSuffix(dirEntry.Name(), ".pem")] = privateKey
+		keys[strings.TrimSuffix(dirEntry.Name(), ".pem")] = privateKey
 
 		return nil
 	}
@@ -68,25 +131,37 @@ func NewFs(fsys fs.FS) (*KeyStore, error) {
 		return nil, fmt.Errorf("walking directory: %w", err)
 	}
 
-	return ks, nil
-
+	return keys, nil
 }
 
-func (ks *KeyStore) Add(privateKey *rsa.PrivateKey, kid string) {
+func (ks *KeyStore) Add(privateKey crypto.Signer, kid string) {
 	ks.mu.Lock()
 	defer ks.mu.Unlock()
 	ks.store[kid] = privateKey
+	delete(ks.retired, kid)
 }
 
-func (ks *KeyStore) Remove(kid string) {
+// Remove retires the key like Reload does for a key whose file has gone. It
+// stops signing right away but its public key stays available for the grace
+// period.
+func (ks *KeyStore) Remove(kid string, grace time.Duration) {
 	ks.mu.Lock()
 	defer ks.mu.Unlock()
+	ks.retire(kid, time.Now(), grace)
 	delete(ks.store, kid)
 }
 
-func (ks *KeyStore) PrivateKey(kid string) (*rsa.PrivateKey, error) {
-	ks.mu.Lock()
-	defer ks.mu.Unlock()
+// retire moves the key to the retired set until the grace period ends. The
+// caller must hold the lock.
+func (ks *KeyStore) retire(kid string, now time.Time, grace time.Duration) {
+	if key, exists := ks.store[kid]; exists {
+		ks.retired[kid] = retiredKey{key: key, until: now.Add(grace)}
+	}
+}
+
+func (ks *KeyStore) PrivateKey(kid string) (crypto.PrivateKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
 	privateKey, found := ks.store[kid]
 	if !found {
 		return nil, fmt.Errorf("key %s not found", kid)
@@ -95,14 +170,73 @@ func (ks *KeyStore) PrivateKey(kid string) (*rsa.PrivateKey, error) {
 	return privateKey, nil
 }
 
-func (ks *KeyStore) PublicKey(kid string) (*rsa.PublicKey, error) {
-	ks.mu.Lock()
-	defer ks.mu.Unlock()
+func (ks *KeyStore) PublicKey(kid string) (crypto.PublicKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
 	privateKey, found := ks.store[kid]
 	if !found {
-		return nil, fmt.Errorf("key %s  and corresponding public key not found", kid)
+		rk, retired := ks.retired[kid]
+		if !retired || !time.Now().Before(rk.until) {
+			return nil, fmt.Errorf("key %s  and corresponding public key not found", kid)
+		}
+		privateKey = rk.key
 	}
 
-	return &privateKey.PublicKey, nil
+	return privateKey.Public(), nil
+
+}
+
+// PublicKeys returns the public half of every key in the store by kid,
+// including retired keys that are still within their grace period.
+func (ks *KeyStore) PublicKeys() (map[string]crypto.PublicKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	now := time.Now()
+
+	keys := make(map[string]crypto.PublicKey, len(ks.store)+len(ks.retired))
+	for kid, rk := range ks.retired {
+		if now.Before(rk.until) {
+			keys[kid] = rk.key.Public()
+		}
+	}
+	for kid, privateKey := range ks.store {
+		keys[kid] = privateKey.Public()
+	}
+
+	return keys, nil
+}
+
+// ParsePrivateKey decodes a PEM encoded private key. PKCS#1 RSA keys, SEC 1
+// EC keys and PKCS#8 keys of any supported type are accepted.
+func ParsePrivateKey(privatePEM []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(privatePEM)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch pk := key.(type) {
+		case *rsa.PrivateKey:
+			return pk, nil
+		case *ecdsa.PrivateKey:
+			return pk, nil
+		case ed25519.PrivateKey:
+			return pk, nil
+		}
+		return nil, fmt.Errorf("unsupported PKCS#8 key type %T", key)
+	}
 
+	return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
 }
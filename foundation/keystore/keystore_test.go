@@ -0,0 +1,198 @@
+package keystore_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/Avyukth/service3-clone/foundation/keystore"
+)
+
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestNewFs(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Should be able to generate rsa key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate ecdsa key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate ed25519 key: %v", err)
+	}
+
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("Should be able to marshal ecdsa key: %v", err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatalf("Should be able to marshal ed25519 key: %v", err)
+	}
+
+	fsys := fstest.MapFS{
+		"rsa.pem":    {Data: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})},
+		"ec.pem":     {Data: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER})},
+		"ed.pem":     {Data: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edDER})},
+		"readme.txt": {Data: []byte("ignored")},
+	}
+
+	t.Log("Given the need to load private keys from disk.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen the folder holds RSA, ECDSA and Ed25519 keys.", testID)
+		{
+			ks, err := keystore.NewFs(fsys)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to load the keys: %v", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to load the keys.", success, testID)
+
+			want := map[string]crypto.PublicKey{
+				"rsa": &rsaKey.PublicKey,
+				"ec":  &ecKey.PublicKey,
+				"ed":  edKey.Public(),
+			}
+
+			got, err := ks.PublicKeys()
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to list the public keys: %v", failed, testID, err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("\t%s\tTest %d:\tShould have %d keys, got %d.", failed, testID, len(want), len(got))
+			}
+
+			for kid, pub := range want {
+				k, ok := got[kid].(interface{ Equal(crypto.PublicKey) bool })
+				if !ok || !k.Equal(pub) {
+					t.Fatalf("\t%s\tTest %d:\tShould have the public key for kid %s.", failed, testID, kid)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould have the public key for every kid.", success, testID)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	encode := func(t *testing.T) []byte {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("Should be able to generate rsa key: %v", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	}
+
+	fsys := fstest.MapFS{
+		"old.pem": {Data: encode(t)},
+	}
+
+	t.Log("Given the need to rotate keys without a restart.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen a key is added and another removed.", testID)
+		{
+			ks, err := keystore.NewFs(fsys)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to load the keys: %v", failed, testID, err)
+			}
+
+			delete(fsys, "old.pem")
+			fsys["new.pem"] = &fstest.MapFile{Data: encode(t)}
+
+			if err := ks.Reload(fsys, "new", time.Hour); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to reload the keys: %v", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to reload the keys.", success, testID)
+
+			if _, err := ks.PrivateKey("new"); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to sign with the new key: %v", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to sign with the new key.", success, testID)
+
+			if _, err := ks.PrivateKey("old"); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to sign with the removed key.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to sign with the removed key.", success, testID)
+
+			if _, err := ks.PublicKey("old"); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould still validate with the removed key during the grace period: %v", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould still validate with the removed key during the grace period.", success, testID)
+
+			if err := ks.Reload(fsys, "new", 0); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to reload the keys: %v", failed, testID, err)
+			}
+			if _, err := ks.PublicKey("old"); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould keep the grace period of an already retired key: %v", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould keep the grace period of an already retired key.", success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen the key of the active kid is removed.", testID)
+		{
+			fsys := fstest.MapFS{
+				"active.pem": {Data: encode(t)},
+				"other.pem":  {Data: encode(t)},
+			}
+			ks, err := keystore.NewFs(fsys)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to load the keys: %v", failed, testID, err)
+			}
+
+			delete(fsys, "active.pem")
+			delete(fsys, "other.pem")
+			fsys["next.pem"] = &fstest.MapFile{Data: encode(t)}
+
+			if err := ks.Reload(fsys, "active", time.Hour); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould fail to reload without the active key.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould fail to reload without the active key.", success, testID)
+
+			for _, kid := range []string{"active", "other"} {
+				if _, err := ks.PrivateKey(kid); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould keep signing with the previous keys: %v", failed, testID, err)
+				}
+			}
+			if _, err := ks.PrivateKey("next"); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould not pick up keys from a failed reload.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould keep the previous keys.", success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen a key is removed by hand.", testID)
+		{
+			ks, err := keystore.NewFs(fstest.MapFS{"kid.pem": {Data: encode(t)}})
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to load the keys: %v", failed, testID, err)
+			}
+
+			ks.Remove("kid", time.Hour)
+
+			if _, err := ks.PrivateKey("kid"); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould not be able to sign with the removed key.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not be able to sign with the removed key.", success, testID)
+
+			if _, err := ks.PublicKey("kid"); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould still validate with the removed key during the grace period: %v", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould still validate with the removed key during the grace period.", success, testID)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Package ratelimit provides a keyed token bucket rate limiter.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter hands out tokens from a separate bucket per key. Each bucket holds
+// up to limit tokens and is refilled at limit tokens per period.
+type Limiter struct {
+	mu        sync.Mutex
+	limit     float64
+	rate      float64 // tokens per second
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New constructs a Limiter that allows limit requests per period for each key.
+func New(limit int, period time.Duration) *Limiter {
+	return &Limiter{
+		limit:   float64(limit),
+		rate:    float64(limit) / period.Seconds(),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from the bucket for key. When the bucket is empty it
+// reports false along with how long until the next token is available.
+func (l *Limiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{tokens: l.limit, last: now}
+		l.buckets[key] = b
+	}
+
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(l.limit, b.tokens+elapsed*l.rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep drops buckets that have had time to refill completely, since a new
+// bucket for the same key would behave identically. It runs at most once per
+// refill period.
+func (l *Limiter) sweep(now time.Time) {
+	full := time.Duration(l.limit / l.rate * float64(time.Second))
+	if now.Sub(l.lastSweep) < full {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, key)
+		}
+	}
+}
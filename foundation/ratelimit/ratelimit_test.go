@@ -0,0 +1,51 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Avyukth/service3-clone/foundation/ratelimit"
+)
+
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestAllow(t *testing.T) {
+	t.Log("Given the need to limit requests per key.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen a key allows 2 requests per second.", testID)
+		{
+			l := ratelimit.New(2, time.Second)
+			now := time.Now()
+
+			for i := 0; i < 2; i++ {
+				if ok, _ := l.Allow("a", now); !ok {
+					t.Fatalf("\t%s\tTest %d:\tShould allow request %d of the burst.", failed, testID, i)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould allow the burst.", success, testID)
+
+			ok, wait := l.Allow("a", now)
+			if ok {
+				t.Fatalf("\t%s\tTest %d:\tShould reject the request over the limit.", failed, testID)
+			}
+			if wait != 500*time.Millisecond {
+				t.Fatalf("\t%s\tTest %d:\tShould wait 500ms for the next token, got %v.", failed, testID, wait)
+			}
+			t.Logf("\t%s\tTest %d:\tShould reject the request over the limit.", success, testID)
+
+			if ok, _ := l.Allow("b", now); !ok {
+				t.Fatalf("\t%s\tTest %d:\tShould keep a separate bucket per key.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould keep a separate bucket per key.", success, testID)
+
+			if ok, _ := l.Allow("a", now.Add(wait)); !ok {
+				t.Fatalf("\t%s\tTest %d:\tShould allow the request once a token is refilled.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould allow the request once a token is refilled.", success, testID)
+		}
+	}
+}
@@ -12,8 +12,11 @@ const (
 	key ctxKey = 1
 )
 
+// Values represent state for each request. Route is the template the
+// handler was registered with, e.g. /v1/users/:id.
 type Values struct {
 	TraceID    string
+	Route      string
 	Now        time.Time
 	StatusCode int
 }
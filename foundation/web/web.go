@@ -46,12 +46,18 @@ func (a *App) Handle(method string, group string, path string, handler Handler,
 
 	handler = wrapMiddleware(a.mw, handler)
 
+	finalPath := path
+	if group != "" {
+		finalPath = "/" + group + path
+	}
+
 	h := func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
 		span := trace.SpanFromContext(ctx)
 		v := Values{
 			TraceID: span.SpanContext().SpanID().String(),
+			Route:   finalPath,
 			Now:     time.Now(),
 		}
 
@@ -64,9 +70,5 @@ func (a *App) Handle(method string, group string, path string, handler Handler,
 
 	}
 
-	finalPath := path
-	if group != "" {
-		finalPath = "/" + group + path
-	}
 	a.mux.Handle(method, finalPath, h)
 }